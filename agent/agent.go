@@ -4,10 +4,13 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/collector"
 	"github.com/steadybit/steadybit-debug/config"
 	"github.com/steadybit/steadybit-debug/k8s"
+	"github.com/steadybit/steadybit-debug/threaddump"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"net/url"
@@ -18,52 +21,68 @@ import (
 	"time"
 )
 
-func AddAgentDebuggingInformation(cfg *config.Config) {
+func init() {
+	collector.Register(collector.WithPermissions(collector.New("agent", "Steadybit agent stateful set, pods, logs, agent endpoints and connection tests", nil, func(ctx context.Context, sink collector.Sink) error {
+		return AddAgentDebuggingInformation(ctx, sink.Config())
+	}), permissions))
+}
+
+func permissions(cfg *config.Config) []collector.Permission {
+	result := k8s.WorkloadPermissions(cfg.Agent.Namespace, "statefulsets")
+	result = append(result, k8s.PodPermissions(cfg.Agent.Namespace)...)
+	result = append(result, k8s.PortForwardPermission(cfg.Agent.Namespace))
+	return append(result, k8s.ConnectionTestPermissions(cfg, cfg.Agent.Namespace)...)
+}
+
+func AddAgentDebuggingInformation(ctx context.Context, cfg *config.Config) error {
 	var wg sync.WaitGroup
+	var err error
 	wg.Add(1)
 
 	go func() {
 		defer wg.Done()
-		statefulSet, err := k8s.FindStatefulSet(cfg, cfg.Agent.Namespace, cfg.Agent.StatefulSet)
-		if err != nil {
-			log.Warn().Msgf("Failed to find agent stateful set '%s' in '%s': %s", cfg.Agent.StatefulSet, cfg.Agent.Namespace, err)
+		statefulSet, findErr := k8s.FindStatefulSet(ctx, cfg, cfg.Agent.Namespace, cfg.Agent.StatefulSet)
+		if findErr != nil {
+			err = fmt.Errorf("failed to find agent stateful set '%s' in '%s': %w", cfg.Agent.StatefulSet, cfg.Agent.Namespace, findErr)
 		} else {
-			addAgentDebuggingData(cfg, filepath.Join(cfg.OutputPath, "agent"), statefulSet.Namespace, statefulSet.Name, "statefulset", statefulSet.Spec.Selector)
+			addAgentDebuggingData(ctx, cfg, filepath.Join(cfg.OutputPath, "agent"), statefulSet.Namespace, statefulSet.Name, "statefulset", statefulSet.Spec.Selector)
 		}
 	}()
 
 	wg.Wait()
+	return err
 }
 
-func addAgentDebuggingData(cfg *config.Config, outputPath string, namespace string, name string, kind string, selector *metav1.LabelSelector) {
+func addAgentDebuggingData(ctx context.Context, cfg *config.Config, outputPath string, namespace string, name string, kind string, selector *metav1.LabelSelector) {
 	pathForAgent := outputPath
-	k8s.AddDescription(cfg, filepath.Join(pathForAgent, "description.txt"), kind, namespace, name)
-	k8s.AddConfig(cfg, filepath.Join(pathForAgent, "config.yaml"), kind, namespace, name)
+	k8s.AddDescription(ctx, cfg, filepath.Join(pathForAgent, "description.txt"), kind, namespace, name)
+	k8s.AddConfig(ctx, cfg, filepath.Join(pathForAgent, "config.yaml"), kind, namespace, name)
 
-	k8s.ForEachPod(cfg, namespace, selector, func(pod *v1.Pod, _ int) {
+	k8s.ForEachPod(ctx, cfg, namespace, selector, func(pod *v1.Pod, _ int) {
 		pathForPod := filepath.Join(pathForAgent, "pods", pod.Name)
 		port := identifyPodPort(pod)
 		delay := time.Millisecond * 500
+		threadDumpInterval := cfg.ThreadDumps.Interval.Duration()
 		platformUrl := identifyPlatformUrl(pod)
 
-		k8s.AddDescription(cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
-		k8s.AddConfig(cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
-		k8s.AddLogs(cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
-		k8s.AddPreviousLogs(cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
-		k8s.AddResourceUsage(cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 10)
+		k8s.AddDescription(ctx, cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
+		k8s.AddConfig(ctx, cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
+		k8s.AddLogs(ctx, cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
+		k8s.AddPreviousLogs(ctx, cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
+		k8s.AddResourceUsage(ctx, cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 10)
 
-		k8s.AddHttpConnectionTest(cfg, filepath.Join(pathForPod, "platform_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl+"/agent")
+		k8s.AddHttpConnectionTest(ctx, cfg, filepath.Join(pathForPod, "platform_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl+"/agent")
 		url, err := url.Parse(platformUrl)
 		if err != nil {
 			log.Err(err).Msgf("Failed to parse platform url '%s'", platformUrl)
 		} else {
-			k8s.AddTracerouteConnectionTest(cfg, filepath.Join(pathForPod, "platform_traceroute_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, url.Host)
+			k8s.AddTracerouteConnectionTest(ctx, cfg, filepath.Join(pathForPod, "platform_traceroute_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, url.Host)
 		}
-		k8s.AddWebsocketCurlHttp1ConnectionTest(cfg, filepath.Join(pathForPod, "platform_websocket_http1_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl)
-		k8s.AddWebsocketCurlHttp2ConnectionTest(cfg, filepath.Join(pathForPod, "platform_websocket_http2_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl)
-		k8s.AddWebsocketWebsocatConnectionTest(cfg, filepath.Join(pathForPod, "platform_websocat_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl)
+		k8s.AddWebsocketCurlHttp1ConnectionTest(ctx, cfg, filepath.Join(pathForPod, "platform_websocket_http1_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl)
+		k8s.AddWebsocketCurlHttp2ConnectionTest(ctx, cfg, filepath.Join(pathForPod, "platform_websocket_http2_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl)
+		k8s.AddWebsocketWebsocatConnectionTest(ctx, cfg, filepath.Join(pathForPod, "platform_websocat_connection_test.txt"), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, platformUrl)
 
-		k8s.AddPodHttpMultipleEndpointOutput(
+		k8s.AddPodHttpMultipleEndpointOutput(ctx,
 			k8s.AddPodHttpEndpointsOutputOptions{
 				SharedPort: port,
 				PodConfig: k8s.PodConfig{
@@ -86,8 +105,10 @@ func addAgentDebuggingData(cfg *config.Config, outputPath string, namespace stri
 						Executions:             10,
 						DelayBetweenExecutions: &delay,
 					}, {
-						OutputPath: filepath.Join(pathForPod, "threaddump.yml"),
-						Url:        fmt.Sprintf("http://localhost:%d/threaddump", port),
+						OutputPath:             filepath.Join(pathForPod, threaddump.FileName(cfg.ThreadDumps.Count)),
+						Url:                    fmt.Sprintf("http://localhost:%d/threaddump", port),
+						Executions:             cfg.ThreadDumps.Count,
+						DelayBetweenExecutions: &threadDumpInterval,
 					},
 					{
 						OutputPath: filepath.Join(pathForPod, "info.yml"),
@@ -117,13 +138,13 @@ func addAgentDebuggingData(cfg *config.Config, outputPath string, namespace stri
 				},
 			})
 
-		extensionConnections := k8s.GetExtensionConnections(port, k8s.PodConfig{
+		extensionConnections := k8s.GetExtensionConnections(ctx, port, k8s.PodConfig{
 			PodNamespace: pod.Namespace,
 			PodName:      pod.Name,
 			Config:       cfg,
 		}, cfg)
 		for idx, extensionConnection := range extensionConnections {
-			k8s.AddHttpConnectionTest(cfg, filepath.Join(pathForPod, fmt.Sprintf("extension_connection_test_%d.txt", idx)), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, extensionConnection.Url)
+			k8s.AddHttpConnectionTest(ctx, cfg, filepath.Join(pathForPod, fmt.Sprintf("extension_connection_test_%d.txt", idx)), pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, extensionConnection.Url)
 		}
 	})
 }
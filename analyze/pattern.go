@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package analyze
+
+import (
+	"errors"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"os"
+	"path"
+	"regexp"
+	"sigs.k8s.io/yaml"
+)
+
+// PatternRuleSpec declares a rule that reports files whose collected output contains a line matching a regular
+// expression. It is the format of the rule files passed via 'analyze --rules'.
+type PatternRuleSpec struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Severity    Severity `json:"severity"`
+	// Files are path.Match patterns relative to the bundle root, e.g. 'agent/pods/*/logs.txt'
+	Files []string `json:"files"`
+	// Pattern is matched against every line of the output, the '# ...' comment lines around it are ignored
+	Pattern string `json:"pattern"`
+	// Message may reference submatches of the first matching line, e.g. '$1'
+	Message string `json:"message"`
+}
+
+type patternRule struct {
+	spec    PatternRuleSpec
+	pattern *regexp.Regexp
+}
+
+// NewPatternRule validates spec and creates the rule
+func NewPatternRule(spec PatternRuleSpec) (Rule, error) {
+	if spec.Name == "" {
+		return nil, errors.New("rule without name")
+	}
+	if len(spec.Files) == 0 {
+		return nil, fmt.Errorf("rule '%s' has no files", spec.Name)
+	}
+	for _, pattern := range spec.Files {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("rule '%s' has an invalid file pattern '%s': %w", spec.Name, pattern, err)
+		}
+	}
+	pattern, err := regexp.Compile(spec.Pattern)
+	if err != nil || spec.Pattern == "" {
+		return nil, fmt.Errorf("rule '%s' has an invalid pattern '%s': %v", spec.Name, spec.Pattern, err)
+	}
+	if spec.Severity == "" {
+		spec.Severity = SeverityWarning
+	} else if spec.Severity, err = ParseSeverity(string(spec.Severity)); err != nil {
+		return nil, fmt.Errorf("rule '%s': %w", spec.Name, err)
+	}
+	if spec.Message == "" {
+		spec.Message = spec.Description
+	}
+	return &patternRule{spec: spec, pattern: pattern}, nil
+}
+
+func (r *patternRule) Name() string {
+	return r.spec.Name
+}
+
+func (r *patternRule) Description() string {
+	return r.spec.Description
+}
+
+// Check reports the first matching line of each file and how many lines matched in total
+func (r *patternRule) Check(b *bundle.Bundle) []Finding {
+	var findings []Finding
+	for _, file := range globAll(b, r.spec.Files) {
+		artifact, ok := b.Artifact(file)
+		if !ok {
+			continue
+		}
+		var first *Finding
+		matches := 0
+		for i, line := range artifact.Lines() {
+			submatches := r.pattern.FindStringSubmatchIndex(line)
+			if submatches == nil {
+				continue
+			}
+			matches++
+			if first == nil {
+				message := string(r.pattern.ExpandString(nil, r.spec.Message, line, submatches))
+				first = &Finding{Severity: r.spec.Severity, Message: message, File: file, Line: artifact.BodyLine + i, Evidence: line}
+			}
+		}
+		if first != nil {
+			if matches > 1 {
+				first.Message = fmt.Sprintf("%s (%d matching lines)", first.Message, matches)
+			}
+			findings = append(findings, *first)
+		}
+	}
+	return findings
+}
+
+// LoadRules registers the pattern rules declared in a YAML file:
+//
+//	rules:
+//	  - name: license-expired
+//	    severity: error
+//	    files: [platform/pods/*/logs.txt]
+//	    pattern: 'License (\S+) expired'
+//	    message: The license $1 expired
+func LoadRules(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var rules struct {
+		Rules []PatternRuleSpec `json:"rules"`
+	}
+	if err = yaml.UnmarshalStrict(content, &rules); err != nil {
+		return fmt.Errorf("failed to parse rules '%s': %w", file, err)
+	}
+	for _, spec := range rules.Rules {
+		r, err := NewPatternRule(spec)
+		if err == nil {
+			err = register(r)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid rules '%s': %w", file, err)
+		}
+	}
+	return nil
+}
+
+// globAll returns the files matching any of the patterns without duplicates
+func globAll(b *bundle.Bundle, patterns []string) []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, pattern := range patterns {
+		for _, file := range b.Glob(pattern) {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	rules := `rules:
+  - name: test-license-expired
+    severity: ERROR
+    files: [platform/pods/*/logs.txt]
+    pattern: 'License (\S+) expired'
+    message: The license $1 expired
+`
+	if err := os.WriteFile(file, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadRules(file); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBundle(map[string]string{
+		"platform/pods/platform-0/logs.txt": "# Executed command: kubectl logs platform-0\n\nstarting\nLicense ABC-1 expired\nLicense ABC-2 expired\n",
+		"agent/pods/agent-0/logs.txt":       "License XYZ expired\n",
+	})
+	want := []Finding{{
+		Severity: SeverityError,
+		Message:  "The license ABC-1 expired (2 matching lines)",
+		File:     "platform/pods/platform-0/logs.txt",
+		Line:     4,
+		Evidence: "License ABC-1 expired",
+	}}
+	if got := check(t, "test-license-expired", b); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadRulesRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{
+			name:  "duplicate name",
+			rules: "rules:\n  - name: test-duplicate\n    files: [a]\n    pattern: a\n  - name: test-duplicate\n    files: [b]\n    pattern: b\n",
+			err:   "rule 'test-duplicate' is already registered",
+		},
+		{
+			name:  "name of a built-in rule",
+			rules: "rules:\n  - name: pod-crash-loop\n    files: [a]\n    pattern: a\n",
+			err:   "rule 'pod-crash-loop' is already registered",
+		},
+		{
+			name:  "invalid pattern",
+			rules: "rules:\n  - name: test-invalid-pattern\n    files: [a]\n    pattern: '('\n",
+			err:   "rule 'test-invalid-pattern' has an invalid pattern '('",
+		},
+		{
+			name:  "no files",
+			rules: "rules:\n  - name: test-no-files\n    pattern: a\n",
+			err:   "rule 'test-no-files' has no files",
+		},
+		{
+			name:  "unknown severity",
+			rules: "rules:\n  - name: test-unknown-severity\n    severity: fatal\n    files: [a]\n    pattern: a\n",
+			err:   "unknown severity 'fatal'",
+		},
+		{
+			name:  "unknown field",
+			rules: "rules:\n  - name: test-unknown-field\n    file: [a]\n    pattern: a\n",
+			err:   "failed to parse rules",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "rules.yaml")
+			if err := os.WriteFile(file, []byte(tt.rules), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := LoadRules(file)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
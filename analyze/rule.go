@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+// Package analyze checks a bundle for known problems with a library of rules
+package analyze
+
+import (
+	"fmt"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// ParseSeverity accepts the severity names case-insensitively
+func ParseSeverity(value string) (Severity, error) {
+	switch severity := Severity(strings.ToLower(strings.TrimSpace(value))); severity {
+	case SeverityInfo, SeverityWarning, SeverityError:
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity '%s', expected info, warning or error", value)
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// Finding is a problem a rule found in the bundle
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// File is the evidence relative to the bundle root
+	File string `json:"file,omitempty"`
+	// Line is the 1-based line within File, 0 if the finding refers to the whole file
+	Line int `json:"line,omitempty"`
+	// Evidence is the content of Line
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// Rule checks a bundle for one kind of problem
+type Rule interface {
+	Name() string
+	Description() string
+	Check(b *bundle.Bundle) []Finding
+}
+
+type CheckFunc func(b *bundle.Bundle) []Finding
+
+type ruleFunc struct {
+	name        string
+	description string
+	check       CheckFunc
+}
+
+// New creates a rule from a function
+func New(name string, description string, check CheckFunc) Rule {
+	return &ruleFunc{name: name, description: description, check: check}
+}
+
+func (r *ruleFunc) Name() string {
+	return r.name
+}
+
+func (r *ruleFunc) Description() string {
+	return r.description
+}
+
+func (r *ruleFunc) Check(b *bundle.Bundle) []Finding {
+	return r.check(b)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]Rule{}
+)
+
+// Register adds a rule to the registry. It is meant to be called from init functions and panics on duplicate names.
+func Register(r Rule) {
+	if err := register(r); err != nil {
+		panic(err.Error())
+	}
+}
+
+func register(r Rule) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[r.Name()]; exists {
+		return fmt.Errorf("rule '%s' is already registered", r.Name())
+	}
+	registry[r.Name()] = r
+	return nil
+}
+
+// All returns all registered rules sorted by name
+func All() []Rule {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	result := make([]Rule, 0, len(registry))
+	for _, r := range registry {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result
+}
+
+// Run checks the bundle with all registered rules and returns the findings of at least minSeverity, the most severe
+// first and then ordered by file and line
+func Run(b *bundle.Bundle, minSeverity Severity) []Finding {
+	var findings []Finding
+	for _, r := range All() {
+		for _, finding := range r.Check(b) {
+			finding.Rule = r.Name()
+			if finding.Severity.rank() >= minSeverity.rank() {
+				findings = append(findings, finding)
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity.rank() != b.Severity.rank() {
+			return a.Severity.rank() > b.Severity.rank()
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return findings
+}
@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"github.com/steadybit/steadybit-debug/connectivity"
+	"github.com/steadybit/steadybit-debug/output"
+	"github.com/steadybit/steadybit-debug/threaddump"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	podDescriptions = []string{"agent/pods/*/description.txt", "platform/pods/*/description.txt", "extensions/*/*/pods/*/description.txt"}
+	connectionTests = []string{
+		"agent/pods/*/platform_connection_test.txt",
+		"agent/pods/*/platform_websocket_*_connection_test.txt",
+		"agent/pods/*/platform_websocat_connection_test.txt",
+		"agent/pods/*/extension_connection_test_*.txt",
+	}
+	extensionEndpoints = []string{"extensions/*/*/pods/*/http/*.yml", "extensions/*/*/pods/*/https/*.yml"}
+	healthEndpoints    = []string{"agent/pods/*/health.yml", "platform/pods/*/health.yml"}
+
+	curlErrorPattern  = regexp.MustCompile(`^curl: \((\d+)\) (.*)$`)
+	curlStatusPattern = regexp.MustCompile(`^< HTTP/[\d.]+ (\d{3})`)
+)
+
+func init() {
+	for _, spec := range []PatternRuleSpec{
+		{
+			Name:        "pod-crash-loop",
+			Description: "A container of a Steadybit pod is in CrashLoopBackOff",
+			Severity:    SeverityError,
+			Files:       podDescriptions,
+			Pattern:     `Reason:\s+CrashLoopBackOff`,
+			Message:     "A container is in CrashLoopBackOff, check logs_previous.txt for the reason",
+		},
+		{
+			Name:        "pod-oom-killed",
+			Description: "A container of a Steadybit pod was killed because it exceeded its memory limit",
+			Severity:    SeverityError,
+			Files:       podDescriptions,
+			Pattern:     `Reason:\s+OOMKilled`,
+			Message:     "A container was OOMKilled, consider raising its memory limit",
+		},
+		{
+			Name:        "pod-restarts",
+			Description: "A container of a Steadybit pod restarted",
+			Severity:    SeverityWarning,
+			Files:       podDescriptions,
+			Pattern:     `Restart Count:\s+([1-9]\d*)`,
+			Message:     "A container restarted $1 times",
+		},
+		{
+			Name:        "node-not-ready",
+			Description: "A node the agent runs on is not ready",
+			Severity:    SeverityError,
+			Files:       []string{"nodes/*/description.txt"},
+			Pattern:     `^\s+Ready\s+(False|Unknown)\s`,
+			Message:     "The node is not ready (Ready=$1)",
+		},
+		{
+			Name:        "node-pressure",
+			Description: "A node the agent runs on reports memory, disk or PID pressure",
+			Severity:    SeverityWarning,
+			Files:       []string{"nodes/*/description.txt"},
+			Pattern:     `^\s+(MemoryPressure|DiskPressure|PIDPressure)\s+True\s`,
+			Message:     "The node reports $1",
+		},
+	} {
+		r, err := NewPatternRule(spec)
+		if err != nil {
+			panic(err.Error())
+		}
+		Register(r)
+	}
+
+	Register(New("connection-test-failed", "A connection test from the agent to the platform or an extension failed", checkConnectionTests))
+	Register(New("extension-endpoint-failed", "An extension endpoint didn't respond with status 200", checkExtensionEndpoints))
+	Register(New("discovery-no-targets", "An extension discovery returned no targets", checkDiscoveredTargets))
+	Register(New("health-down", "The health endpoint of the agent or the platform doesn't report UP", checkHealth))
+	Register(New("thread-deadlock", "A thread dump of the platform or the agent contains deadlocked threads", checkDeadlocks))
+	Register(New("stuck-threads", "Threads of the platform or the agent didn't move between several thread dumps", checkStuckThreads))
+	Register(New("collection-failed", "Artifacts of a collector could not be collected, which limits the analysis", checkManifest))
+}
+
+func checkConnectionTests(b *bundle.Bundle) []Finding {
+	var findings []Finding
+	for _, file := range globAll(b, connectionTests) {
+		artifact, ok := b.Artifact(file)
+		if !ok {
+			continue
+		}
+		finding := Finding{Severity: SeverityError, File: file}
+		status := 0
+		for i, line := range artifact.Lines() {
+			if m := curlErrorPattern.FindStringSubmatch(line); m != nil {
+				finding.Message = fmt.Sprintf("curl failed with exit code %s: %s", m[1], m[2])
+				finding.Line, finding.Evidence = artifact.BodyLine+i, line
+				break
+			}
+			if m := curlStatusPattern.FindStringSubmatch(line); m != nil {
+				status, _ = strconv.Atoi(m[1])
+				finding.Line, finding.Evidence = artifact.BodyLine+i, line
+			}
+		}
+		// websocat output has no curl lines and yields a verdict without stage
+		verdict := connectivity.ParseCurl(artifact.Body)
+		switch {
+		case verdict.Ok:
+			continue
+		case verdict.FailedStage != "":
+			// the verdict names the failing stage, which is more telling than the curl error
+			finding.Message = fmt.Sprintf("The connection test failed at the %s stage: %s", verdict.FailedStage, verdict.Cause)
+		case finding.Message != "":
+		case status >= 400:
+			finding.Message = fmt.Sprintf("The connection test received status %d", status)
+		case artifact.Error() != "":
+			finding.Message = fmt.Sprintf("The connection test failed: %s", artifact.Error())
+			finding.Line, finding.Evidence = 0, ""
+		default:
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func checkDeadlocks(b *bundle.Bundle) []Finding {
+	var findings []Finding
+	for _, digest := range threaddump.Digests(b) {
+		for _, cycle := range digest.Deadlocks {
+			threads := make([]string, len(cycle))
+			for i, wait := range cycle {
+				threads[i] = fmt.Sprintf("'%s'", wait.Thread)
+			}
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				File:     digest.Dumps[len(digest.Dumps)-1],
+				Message:  fmt.Sprintf("Deadlock between %s, see %s", strings.Join(threads, ", "), threaddump.DigestFile),
+			})
+		}
+	}
+	return findings
+}
+
+// maxStuckThreads limits the threads named in a stuck-threads finding
+const maxStuckThreads = 5
+
+func checkStuckThreads(b *bundle.Bundle) []Finding {
+	var findings []Finding
+	for _, digest := range threaddump.Digests(b) {
+		if len(digest.Stuck) == 0 {
+			continue
+		}
+		var threads []string
+		for i, stuck := range digest.Stuck {
+			if i == maxStuckThreads {
+				threads = append(threads, fmt.Sprintf("and %d more", len(digest.Stuck)-maxStuckThreads))
+				break
+			}
+			threads = append(threads, fmt.Sprintf("'%s' (%s)", stuck.Thread, stuck.State))
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			File:     digest.Dumps[len(digest.Dumps)-1],
+			Message: fmt.Sprintf("%d threads didn't move in %d dumps over %s: %s, see %s", len(digest.Stuck), len(digest.Dumps), digest.Span,
+				strings.Join(threads, ", "), threaddump.DigestFile),
+		})
+	}
+	return findings
+}
+
+func checkExtensionEndpoints(b *bundle.Bundle) []Finding {
+	var findings []Finding
+	for _, file := range globAll(b, extensionEndpoints) {
+		artifact, ok := b.Artifact(file)
+		if !ok || artifact.Error() == "" {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s failed: %s", endpoint(artifact), artifact.Error()),
+			File:     file,
+		})
+	}
+	return findings
+}
+
+// endpoint returns the method and path of an HTTP artifact, e.g. 'GET /discovery'
+func endpoint(artifact bundle.Artifact) string {
+	method, rawUrl, found := strings.Cut(artifact.Header["Executed command"], " ")
+	if !found {
+		return "The endpoint"
+	}
+	if _, p, found := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(rawUrl, "http://"), "https://"), "/"); found {
+		return fmt.Sprintf("%s /%s", method, p)
+	}
+	return fmt.Sprintf("%s /", method)
+}
+
+func checkDiscoveredTargets(b *bundle.Bundle) []Finding {
+	var findings []Finding
+	for _, file := range globAll(b, extensionEndpoints) {
+		artifact, ok := b.Artifact(file)
+		if !ok || artifact.Error() != "" {
+			continue
+		}
+		var body map[string]json.RawMessage
+		if json.Unmarshal([]byte(artifact.Body), &body) != nil {
+			continue
+		}
+		var targets []json.RawMessage
+		if raw, ok := body["targets"]; ok && json.Unmarshal(raw, &targets) == nil && len(targets) == 0 {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s discovered no targets", endpoint(artifact)),
+				File:     file,
+				Line:     lineOf(artifact, `"targets"`),
+			})
+		}
+	}
+	return findings
+}
+
+func checkHealth(b *bundle.Bundle) []Finding {
+	var findings []Finding
+	for _, file := range globAll(b, healthEndpoints) {
+		artifact, ok := b.Artifact(file)
+		if !ok || artifact.Error() != "" {
+			continue
+		}
+		var health struct {
+			Status     string `json:"status"`
+			Components map[string]struct {
+				Status string `json:"status"`
+			} `json:"components"`
+		}
+		if json.Unmarshal([]byte(artifact.Body), &health) != nil || health.Status == "" || health.Status == "UP" {
+			continue
+		}
+		var down []string
+		for name, component := range health.Components {
+			if component.Status != "UP" {
+				down = append(down, fmt.Sprintf("%s=%s", name, component.Status))
+			}
+		}
+		sort.Strings(down)
+		message := fmt.Sprintf("The health status is %s", health.Status)
+		if len(down) > 0 {
+			message = fmt.Sprintf("%s (%s)", message, strings.Join(down, ", "))
+		}
+		findings = append(findings, Finding{Severity: SeverityError, Message: message, File: file, Line: lineOf(artifact, `"status"`)})
+	}
+	return findings
+}
+
+// checkManifest reports one finding per collector with failed artifacts and its most frequent error
+func checkManifest(b *bundle.Bundle) []Finding {
+	content, ok := b.Read("manifest.json")
+	if !ok {
+		return nil
+	}
+	var manifest output.Manifest
+	if json.Unmarshal(content, &manifest) != nil {
+		return nil
+	}
+	failed := map[string]map[string]int{}
+	for _, artifact := range manifest.Artifacts {
+		if artifact.Status != output.ArtifactFailed || artifact.Collector == "" {
+			continue
+		}
+		if failed[artifact.Collector] == nil {
+			failed[artifact.Collector] = map[string]int{}
+		}
+		failed[artifact.Collector][artifact.Error]++
+	}
+	collectors := make([]string, 0, len(failed))
+	for collector := range failed {
+		collectors = append(collectors, collector)
+	}
+	sort.Strings(collectors)
+	var findings []Finding
+	for _, collector := range collectors {
+		errs := failed[collector]
+		total, common, commonCount := 0, "", 0
+		for err, count := range errs {
+			total += count
+			if count > commonCount || (count == commonCount && err < common) {
+				common, commonCount = err, count
+			}
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("%d artifacts of collector '%s' could not be collected, most often: %s", total, collector, common),
+			File:     "manifest.json",
+		})
+	}
+	return findings
+}
+
+// lineOf returns the line number of the first line of the body containing text, 0 if there is none
+func lineOf(artifact bundle.Artifact, text string) int {
+	for i, line := range artifact.Lines() {
+		if strings.Contains(line, text) {
+			return artifact.BodyLine + i
+		}
+	}
+	return 0
+}
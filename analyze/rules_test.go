@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package analyze
+
+import (
+	"github.com/steadybit/steadybit-debug/bundle"
+	"reflect"
+	"testing"
+)
+
+const crashLoopDescription = `# Executed command: kubectl describe pod steadybit-agent-0 -n steadybit-agent
+# Started at: 2026-01-01T00:00:00Z
+
+Name:         steadybit-agent-0
+Containers:
+  steadybit-agent:
+    State:          Waiting
+      Reason:       CrashLoopBackOff
+    Last State:     Terminated
+      Reason:       Error
+    Restart Count:  7
+
+# Total execution time: 120 millis
+`
+
+const discoveryPath = "extensions/steadybit-agent/extension-http/pods/extension-http-0/http/discovery.yml"
+
+func TestBuiltInRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		files    map[string]string
+		findings []Finding
+	}{
+		{
+			name:  "crash loop",
+			rule:  "pod-crash-loop",
+			files: map[string]string{"agent/pods/steadybit-agent-0/description.txt": crashLoopDescription},
+			findings: []Finding{{
+				Severity: SeverityError,
+				Message:  "A container is in CrashLoopBackOff, check logs_previous.txt for the reason",
+				File:     "agent/pods/steadybit-agent-0/description.txt",
+				Line:     8,
+				Evidence: "      Reason:       CrashLoopBackOff",
+			}},
+		},
+		{
+			name:  "restart count",
+			rule:  "pod-restarts",
+			files: map[string]string{"agent/pods/steadybit-agent-0/description.txt": crashLoopDescription},
+			findings: []Finding{{
+				Severity: SeverityWarning,
+				Message:  "A container restarted 7 times",
+				File:     "agent/pods/steadybit-agent-0/description.txt",
+				Line:     11,
+				Evidence: "    Restart Count:  7",
+			}},
+		},
+		{
+			name: "no restarts",
+			rule: "pod-restarts",
+			files: map[string]string{
+				"agent/pods/steadybit-agent-0/description.txt": "# Executed command: kubectl describe pod\n\n    Restart Count:  0\n",
+			},
+		},
+		{
+			name: "extension endpoint with status 500",
+			rule: "extension-endpoint-failed",
+			files: map[string]string{
+				discoveryPath: "# Executed command: GET http://10.0.0.1:8085/discovery\n# Started at: 2026-01-01T00:00:00Z\n" +
+					"# Resulted in error: request failed with status code 500\n\n\n# Total execution time: 5 millis\n",
+			},
+			findings: []Finding{{
+				Severity: SeverityError,
+				Message:  "GET /discovery failed: request failed with status code 500",
+				File:     discoveryPath,
+			}},
+		},
+		{
+			name: "extension endpoint with status 200",
+			rule: "extension-endpoint-failed",
+			files: map[string]string{
+				discoveryPath: "# Executed command: GET http://10.0.0.1:8085/discovery\n# Started at: 2026-01-01T00:00:00Z\n\n{}\n",
+			},
+		},
+		{
+			name: "empty targets",
+			rule: "discovery-no-targets",
+			files: map[string]string{
+				discoveryPath: "# Executed command: GET http://10.0.0.1:8085/discovery/targets\n# Started at: 2026-01-01T00:00:00Z\n\n" +
+					"{\n  \"targets\": []\n}\n\n# Total execution time: 5 millis\n",
+			},
+			findings: []Finding{{
+				Severity: SeverityWarning,
+				Message:  "GET /discovery/targets discovered no targets",
+				File:     discoveryPath,
+				Line:     5,
+			}},
+		},
+		{
+			name: "discovered targets",
+			rule: "discovery-no-targets",
+			files: map[string]string{
+				discoveryPath: "# Executed command: GET http://10.0.0.1:8085/discovery/targets\n\n{\"targets\": [{\"id\": \"a\"}]}\n",
+			},
+		},
+		{
+			name: "failed discovery is no empty discovery",
+			rule: "discovery-no-targets",
+			files: map[string]string{
+				discoveryPath: "# Executed command: GET http://10.0.0.1:8085/discovery/targets\n# Resulted in error: request failed with status code 404\n\n{\"targets\": []}\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(t, tt.rule, newBundle(tt.files)); !reflect.DeepEqual(got, tt.findings) {
+				t.Errorf("got %+v, want %+v", got, tt.findings)
+			}
+		})
+	}
+}
+
+func TestRunOrdersBySeverity(t *testing.T) {
+	b := newBundle(map[string]string{"agent/pods/steadybit-agent-0/description.txt": crashLoopDescription})
+	findings := Run(b, SeverityWarning)
+	var rules []string
+	for _, finding := range findings {
+		rules = append(rules, finding.Rule)
+	}
+	if want := []string{"pod-crash-loop", "pod-restarts"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("got rules %v, want %v", rules, want)
+	}
+	if findings := Run(b, SeverityError); len(findings) != 1 {
+		t.Errorf("got %d findings of severity error, want 1", len(findings))
+	}
+}
+
+func newBundle(files map[string]string) *bundle.Bundle {
+	contents := make(map[string][]byte, len(files))
+	for p, content := range files {
+		contents[p] = []byte(content)
+	}
+	return bundle.New("steadybit-debug-1", contents)
+}
+
+// check runs the registered rule with the given name
+func check(t *testing.T, name string, b *bundle.Bundle) []Finding {
+	t.Helper()
+	for _, r := range All() {
+		if r.Name() == name {
+			return r.Check(b)
+		}
+	}
+	t.Fatalf("rule '%s' is not registered", name)
+	return nil
+}
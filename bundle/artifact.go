@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package bundle
+
+import (
+	"strings"
+)
+
+// Artifact is a file of the bundle split into the '# Key: value' comment lines written around the collected output,
+// e.g. '# Executed command: ...' and '# Resulted in error: ...', and the output itself
+type Artifact struct {
+	Path string
+	// Header holds the comment lines before and after the body, repeated keys are joined by '; '
+	Header map[string]string
+	Body   string
+	// BodyLine is the 1-based line number of the first line of Body within the file
+	BodyLine int
+}
+
+// Artifact parses the file at p
+func (b *Bundle) Artifact(p string) (Artifact, bool) {
+	content, ok := b.Read(p)
+	if !ok {
+		return Artifact{}, false
+	}
+	return ParseArtifact(p, string(content)), true
+}
+
+// ParseArtifact splits content into header and body. Header lines are the comment lines up to the first blank line and
+// the trailing comment lines.
+func ParseArtifact(p string, content string) Artifact {
+	artifact := Artifact{Path: p, Header: map[string]string{}, BodyLine: 1}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	start := 0
+	for start < len(lines) && artifact.addHeader(lines[start]) {
+		start++
+	}
+	if start > 0 {
+		for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+			start++
+		}
+	}
+	end := len(lines)
+	for end > start && (strings.TrimSpace(lines[end-1]) == "" || isHeader(lines[end-1])) {
+		artifact.addHeader(lines[end-1])
+		end--
+	}
+
+	artifact.BodyLine = start + 1
+	artifact.Body = strings.Join(lines[start:end], "\n")
+	return artifact
+}
+
+func isHeader(line string) bool {
+	key, _, found := strings.Cut(strings.TrimPrefix(line, "# "), ": ")
+	return strings.HasPrefix(line, "# ") && found && !strings.ContainsAny(key, ":/")
+}
+
+func (a *Artifact) addHeader(line string) bool {
+	if !isHeader(line) {
+		return false
+	}
+	key, value, _ := strings.Cut(strings.TrimPrefix(line, "# "), ": ")
+	if existing, ok := a.Header[key]; ok {
+		value = existing + "; " + value
+	}
+	a.Header[key] = value
+	return true
+}
+
+// Error returns the error the collection of the artifact resulted in, if any
+func (a Artifact) Error() string {
+	return a.Header["Resulted in error"]
+}
+
+// Lines returns the lines of the body
+func (a Artifact) Lines() []string {
+	if a.Body == "" {
+		return nil
+	}
+	return strings.Split(a.Body, "\n")
+}
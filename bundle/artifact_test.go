@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArtifact(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		header   map[string]string
+		body     string
+		bodyLine int
+	}{
+		{
+			name: "header, body and trailing header",
+			content: "# Executed command: kubectl describe pod agent-0\n# Started at: 2026-01-01T00:00:00Z\n\n" +
+				"Name: agent-0\nStatus: Running\n\n# Total execution time: 12ms\n",
+			header: map[string]string{
+				"Executed command":     "kubectl describe pod agent-0",
+				"Started at":           "2026-01-01T00:00:00Z",
+				"Total execution time": "12ms",
+			},
+			body:     "Name: agent-0\nStatus: Running",
+			bodyLine: 4,
+		},
+		{
+			name:     "error right after the header",
+			content:  "# Executed request: GET http://localhost:8080/\n# Started at: now\n# Resulted in error: 404\n\n{}\n",
+			header:   map[string]string{"Executed request": "GET http://localhost:8080/", "Started at": "now", "Resulted in error": "404"},
+			body:     "{}",
+			bodyLine: 5,
+		},
+		{
+			name:     "without header",
+			content:  "line 1\nline 2\n",
+			header:   map[string]string{},
+			body:     "line 1\nline 2",
+			bodyLine: 1,
+		},
+		{
+			name:     "comments with colons or slashes are body",
+			content:  "# Executed command: cat\n\n# see: http://example.com\n# a/b: c\ntext\n",
+			header:   map[string]string{"Executed command": "cat"},
+			body:     "# see: http://example.com\n# a/b: c\ntext",
+			bodyLine: 3,
+		},
+		{
+			name:     "repeated trailing keys",
+			content:  "# Executed command: cat\n\nbody\n# Incomplete: timeout\n# Incomplete: cancelled\n",
+			header:   map[string]string{"Executed command": "cat", "Incomplete": "cancelled; timeout"},
+			body:     "body",
+			bodyLine: 3,
+		},
+		{
+			name:     "header only",
+			content:  "# Executed command: cat\n# Resulted in error: exit status 1\n",
+			header:   map[string]string{"Executed command": "cat", "Resulted in error": "exit status 1"},
+			body:     "",
+			bodyLine: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artifact := ParseArtifact("file.txt", tt.content)
+			if !reflect.DeepEqual(artifact.Header, tt.header) {
+				t.Errorf("got header %v, want %v", artifact.Header, tt.header)
+			}
+			if artifact.Body != tt.body {
+				t.Errorf("got body %q, want %q", artifact.Body, tt.body)
+			}
+			if artifact.BodyLine != tt.bodyLine {
+				t.Errorf("got body line %d, want %d", artifact.BodyLine, tt.bodyLine)
+			}
+		})
+	}
+}
+
+func TestArtifactError(t *testing.T) {
+	b := New("bundle", map[string][]byte{
+		"failed.yml": []byte("# Executed request: GET http://localhost/\n# Resulted in error: 500 Internal Server Error\n"),
+		"ok.yml":     []byte("# Executed request: GET http://localhost/\n\n{}\n"),
+	})
+	if artifact, _ := b.Artifact("failed.yml"); artifact.Error() != "500 Internal Server Error" {
+		t.Errorf("got error %q", artifact.Error())
+	}
+	if artifact, _ := b.Artifact("ok.yml"); artifact.Error() != "" || len(artifact.Lines()) != 1 {
+		t.Errorf("got error %q and lines %v", artifact.Error(), artifact.Lines())
+	}
+	if _, ok := b.Artifact("missing.yml"); ok {
+		t.Errorf("got artifact for a missing file")
+	}
+}
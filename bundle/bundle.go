@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+// Package bundle reads the files of a bundle created by steadybit-debug for offline analysis
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxFileSize limits the files that are loaded, bigger ones like database exports are only listed
+const maxFileSize = 32 << 20
+
+// Bundle holds the files of a bundle by their slash-separated path relative to the bundle root, e.g.
+// agent/pods/steadybit-agent-0/description.txt
+type Bundle struct {
+	// Name is the name of the bundle's root directory, e.g. steadybit-debug-1700000000
+	Name  string
+	files map[string][]byte
+	paths []string
+}
+
+// Load reads a bundle from a directory, a .tar.gz or a .zip archive
+func Load(source string) (*Bundle, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	switch {
+	case info.IsDir():
+		err = loadDirectory(source, files)
+	case strings.HasSuffix(source, ".age"):
+		return nil, fmt.Errorf("'%s' is encrypted, decrypt it first", source)
+	case strings.HasSuffix(source, ".index.json"):
+		return nil, fmt.Errorf("'%s' is the index of a split archive, join it first", source)
+	case strings.HasSuffix(source, ".zip"):
+		err = loadZip(source, files)
+	default:
+		err = loadTarGz(source, files)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle '%s': %w", source, err)
+	}
+	name, files := stripRoot(strings.TrimSuffix(filepath.Base(source), filepath.Ext(source)), files)
+	return New(strings.TrimSuffix(name, ".tar"), files), nil
+}
+
+// New creates a bundle from file contents by their slash-separated path relative to the bundle root
+func New(name string, files map[string][]byte) *Bundle {
+	b := &Bundle{Name: name, files: files, paths: make([]string, 0, len(files))}
+	for p := range files {
+		b.paths = append(b.paths, p)
+	}
+	sort.Strings(b.paths)
+	return b
+}
+
+// stripRoot removes the common root directory of archives, e.g. steadybit-debug-1700000000/, and returns it as name
+func stripRoot(name string, files map[string][]byte) (string, map[string][]byte) {
+	root := ""
+	for p := range files {
+		first, _, found := strings.Cut(p, "/")
+		if !found || (root != "" && first != root) {
+			root = ""
+			break
+		}
+		root = first
+	}
+	if root != "" && !hasManifest(files) {
+		name = root
+		stripped := make(map[string][]byte, len(files))
+		for p, content := range files {
+			stripped[strings.TrimPrefix(p, root+"/")] = content
+		}
+		files = stripped
+	}
+	return name, files
+}
+
+func hasManifest(files map[string][]byte) bool {
+	_, ok := files["manifest.json"]
+	return ok
+}
+
+func loadDirectory(root string, files map[string][]byte) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return err
+		}
+		relative, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		return addFile(files, filepath.ToSlash(relative), info.Size(), file)
+	})
+}
+
+func loadTarGz(source string, files map[string][]byte) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err = addFile(files, header.Name, header.Size, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func loadZip(source string, files map[string][]byte) error {
+	zr, err := zip.OpenReader(source)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		r, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		err = addFile(files, entry.Name, int64(entry.UncompressedSize64), r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addFile(files map[string][]byte, name string, size int64, r io.Reader) error {
+	name = path.Clean(strings.TrimPrefix(name, "./"))
+	if size > maxFileSize {
+		files[name] = nil
+		return nil
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	files[name] = content
+	return nil
+}
+
+// Paths returns the paths of all files in lexical order
+func (b *Bundle) Paths() []string {
+	return b.paths
+}
+
+// Read returns the content of the file at p. Files bigger than 32 MiB are listed, but have no content.
+func (b *Bundle) Read(p string) ([]byte, bool) {
+	content, ok := b.files[p]
+	return content, ok && content != nil
+}
+
+// Glob returns the paths matching pattern in lexical order, see path.Match. Wildcards don't match '/'.
+func (b *Bundle) Glob(pattern string) []string {
+	var result []string
+	for _, p := range b.paths {
+		if matched, _ := path.Match(pattern, p); matched {
+			result = append(result, p)
+		}
+	}
+	return result
+}
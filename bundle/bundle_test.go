@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package bundle
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStripRoot(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		root  string
+		paths []string
+	}{
+		{
+			name:  "common root",
+			files: []string{"steadybit-debug-1/log.txt", "steadybit-debug-1/agent/pods/agent-0/logs.txt"},
+			root:  "steadybit-debug-1",
+			paths: []string{"agent/pods/agent-0/logs.txt", "log.txt"},
+		},
+		{
+			name:  "files at the top level",
+			files: []string{"log.txt", "agent/pods/agent-0/logs.txt"},
+			root:  "archive",
+			paths: []string{"agent/pods/agent-0/logs.txt", "log.txt"},
+		},
+		{
+			name:  "different roots",
+			files: []string{"agent/pods/agent-0/logs.txt", "platform/pods/platform-0/logs.txt"},
+			root:  "archive",
+			paths: []string{"agent/pods/agent-0/logs.txt", "platform/pods/platform-0/logs.txt"},
+		},
+		{
+			name:  "bundle with manifest and a single directory",
+			files: []string{"manifest.json", "agent/pods/agent-0/logs.txt"},
+			root:  "archive",
+			paths: []string{"agent/pods/agent-0/logs.txt", "manifest.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for _, p := range tt.files {
+				files[p] = []byte(p)
+			}
+			root, stripped := stripRoot("archive", files)
+			if root != tt.root {
+				t.Errorf("got root %s, want %s", root, tt.root)
+			}
+			var paths []string
+			for p := range stripped {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("got paths %v, want %v", paths, tt.paths)
+			}
+		})
+	}
+}
+
+func TestGlobAndRead(t *testing.T) {
+	b := New("bundle", map[string][]byte{
+		"agent/pods/agent-0/logs.txt":      []byte("log"),
+		"agent/pods/agent-1/logs.txt":      []byte("log"),
+		"agent/pods/agent-0/http/info.yml": []byte("info"),
+		"database.sql":                     nil,
+	})
+	if got := b.Glob("agent/pods/*/logs.txt"); !reflect.DeepEqual(got, []string{"agent/pods/agent-0/logs.txt", "agent/pods/agent-1/logs.txt"}) {
+		t.Errorf("got %v", got)
+	}
+	if got := b.Glob("agent/*/logs.txt"); got != nil {
+		t.Errorf("wildcards must not match '/', got %v", got)
+	}
+	if _, ok := b.Read("database.sql"); ok {
+		t.Errorf("a file without content must not be readable")
+	}
+	if len(b.Paths()) != 4 {
+		t.Errorf("got paths %v", b.Paths())
+	}
+}
+
+func TestComponents(t *testing.T) {
+	b := New("bundle", map[string][]byte{
+		"log.txt":                                   nil,
+		"agent/description.txt":                     nil,
+		"agent/pods/agent-1/logs.txt":               nil,
+		"agent/pods/agent-0/logs.txt":               nil,
+		"agent/pods/agent-0/logs_previous.txt":      nil,
+		"extensions/steadybit/extension-http/a.txt": nil,
+		"extensions/steadybit/extension-http/pods/extension-http-0/logs.txt": nil,
+		"extensions/other/extension-host/pods/extension-host-0/logs.txt":     nil,
+		"extensions/README.txt": nil,
+	})
+	want := []Component{
+		{Name: "agent", Dir: "agent", Pods: []string{"agent/pods/agent-0", "agent/pods/agent-1"}},
+		{Name: "extension other/extension-host", Dir: "extensions/other/extension-host", Pods: []string{"extensions/other/extension-host/pods/extension-host-0"}},
+		{Name: "extension steadybit/extension-http", Dir: "extensions/steadybit/extension-http", Pods: []string{"extensions/steadybit/extension-http/pods/extension-http-0"}},
+	}
+	if got := b.Components(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
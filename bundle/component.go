@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package bundle
+
+import (
+	"path"
+	"strings"
+)
+
+// Component is the platform, the agent or an extension within a bundle
+type Component struct {
+	// Name is 'platform', 'agent' or 'extension <namespace>/<name>'
+	Name string
+	// Dir holds the description and config.yaml of the workload, e.g. extensions/steadybit-agent/extension-http
+	Dir string
+	// Pods are the directories of the component's pods, e.g. agent/pods/steadybit-agent-0
+	Pods []string
+}
+
+// Components returns the platform, the agent and the extensions found in the bundle, in that order
+func (b *Bundle) Components() []Component {
+	var components []Component
+	for _, dir := range []string{"platform", "agent"} {
+		if c, ok := b.component(dir, dir); ok {
+			components = append(components, c)
+		}
+	}
+	seen := map[string]bool{}
+	for _, p := range b.paths {
+		parts := strings.Split(p, "/")
+		if len(parts) < 4 || parts[0] != "extensions" {
+			continue
+		}
+		dir := path.Join(parts[:3]...)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if c, ok := b.component("extension "+parts[1]+"/"+parts[2], dir); ok {
+			components = append(components, c)
+		}
+	}
+	return components
+}
+
+func (b *Bundle) component(name string, dir string) (Component, bool) {
+	c := Component{Name: name, Dir: dir}
+	found := false
+	seen := map[string]bool{}
+	for _, p := range b.paths {
+		if !strings.HasPrefix(p, dir+"/") {
+			continue
+		}
+		found = true
+		rest := strings.Split(strings.TrimPrefix(p, dir+"/"), "/")
+		if len(rest) > 2 && rest[0] == "pods" && !seen[rest[1]] {
+			seen[rest[1]] = true
+			c.Pods = append(c.Pods, path.Join(dir, "pods", rest[1]))
+		}
+	}
+	return c, found
+}
@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/config"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sink receives the artifacts of a single collector
+type Sink interface {
+	Config() *config.Config
+	// OutputPath returns the file system path of an artifact within the bundle
+	OutputPath(elem ...string) string
+	// Write stores content as artifact at the given path relative to the bundle root
+	Write(path string, content []byte)
+}
+
+// Collector gathers one aspect of the debugging information, e.g. everything about the agent.
+// Collectors run concurrently, a collector only starts once all of its dependencies finished.
+type Collector interface {
+	Name() string
+	Description() string
+	Dependencies() []string
+	Collect(ctx context.Context, sink Sink) error
+}
+
+type CollectFunc func(ctx context.Context, sink Sink) error
+
+type collectorFunc struct {
+	name         string
+	description  string
+	dependencies []string
+	collect      CollectFunc
+}
+
+// New creates a collector from a function
+func New(name string, description string, dependencies []string, collect CollectFunc) Collector {
+	return &collectorFunc{
+		name:         name,
+		description:  description,
+		dependencies: dependencies,
+		collect:      collect,
+	}
+}
+
+func (c *collectorFunc) Name() string {
+	return c.name
+}
+
+func (c *collectorFunc) Description() string {
+	return c.description
+}
+
+func (c *collectorFunc) Dependencies() []string {
+	return c.dependencies
+}
+
+func (c *collectorFunc) Collect(ctx context.Context, sink Sink) error {
+	return c.collect(ctx, sink)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]Collector{}
+)
+
+// Register adds a collector to the registry. It is meant to be called from init functions and panics on duplicate names.
+func Register(c Collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[c.Name()]; exists {
+		panic(fmt.Sprintf("collector '%s' is already registered", c.Name()))
+	}
+	registry[c.Name()] = c
+}
+
+// All returns all registered collectors sorted by name
+func All() []Collector {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	result := make([]Collector, 0, len(registry))
+	for _, c := range registry {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result
+}
+
+// Lookup returns the registered collector with the given name
+func Lookup(name string) (Collector, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	c, ok := registry[name]
+	return c, ok
+}
+
+// Select returns the registered collectors to run. An empty only list selects all collectors. Names may be given comma-separated.
+func Select(only []string, skip []string) ([]Collector, error) {
+	all := All()
+	byName := make(map[string]Collector, len(all))
+	for _, c := range all {
+		byName[c.Name()] = c
+	}
+
+	if err := validate(all, byName); err != nil {
+		return nil, err
+	}
+
+	onlyNames, err := parseNames(only, byName)
+	if err != nil {
+		return nil, err
+	}
+	skipNames, err := parseNames(skip, byName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Collector, 0, len(all))
+	for _, c := range all {
+		if len(onlyNames) > 0 && !onlyNames[c.Name()] {
+			continue
+		}
+		if skipNames[c.Name()] {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func parseNames(values []string, byName map[string]Collector) (map[string]bool, error) {
+	names := map[string]bool{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, ok := byName[name]; !ok {
+				return nil, fmt.Errorf("unknown collector '%s'", name)
+			}
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// validate ensures that all dependencies exist and that there are no dependency cycles
+func validate(all []Collector, byName map[string]Collector) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+
+	var visit func(c Collector, path []string) error
+	visit = func(c Collector, path []string) error {
+		switch state[c.Name()] {
+		case visiting:
+			return fmt.Errorf("dependency cycle between collectors: %s", strings.Join(append(path, c.Name()), " -> "))
+		case visited:
+			return nil
+		}
+		state[c.Name()] = visiting
+		for _, dependency := range c.Dependencies() {
+			d, ok := byName[dependency]
+			if !ok {
+				return fmt.Errorf("collector '%s' depends on unknown collector '%s'", c.Name(), dependency)
+			}
+			if err := visit(d, append(path, c.Name())); err != nil {
+				return err
+			}
+		}
+		state[c.Name()] = visited
+		return nil
+	}
+
+	for _, c := range all {
+		if err := visit(c, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/config"
+)
+
+// Permission is a Kubernetes API permission a collector needs
+type Permission struct {
+	Verb        string `json:"verb"`
+	Group       string `json:"group,omitempty"`
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource,omitempty"`
+	// Namespace is empty for cluster-scoped resources and for access across all namespaces
+	Namespace string `json:"namespace,omitempty"`
+	// Optional permissions only reduce the collected information, the collector still runs without them
+	Optional bool `json:"optional,omitempty"`
+}
+
+// String returns the permission like `kubectl auth can-i` expects it, e.g. 'create pods/portforward -n steadybit-agent'
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource = fmt.Sprintf("%s.%s", resource, p.Group)
+	}
+	if p.Subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, p.Subresource)
+	}
+	if p.Namespace == "" {
+		return fmt.Sprintf("%s %s", p.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s -n %s", p.Verb, resource, p.Namespace)
+}
+
+// PermissionsFunc returns the permissions for the given configuration, e.g. within the configured namespaces
+type PermissionsFunc func(cfg *config.Config) []Permission
+
+type collectorWithPermissions struct {
+	Collector
+	permissions PermissionsFunc
+}
+
+// WithPermissions declares the Kubernetes API permissions c needs, so that they can be checked before collection
+func WithPermissions(c Collector, permissions PermissionsFunc) Collector {
+	return &collectorWithPermissions{Collector: c, permissions: permissions}
+}
+
+// Permissions returns the permissions declared via WithPermissions, or nil
+func Permissions(c Collector, cfg *config.Config) []Permission {
+	if withPermissions, ok := c.(*collectorWithPermissions); ok {
+		return withPermissions.permissions(cfg)
+	}
+	return nil
+}
+
+type disabledCollector struct {
+	Collector
+	err error
+}
+
+// Disable returns a collector that doesn't collect anything but fails with err, so that the reason is part of the summary
+func Disable(c Collector, err error) Collector {
+	return &disabledCollector{Collector: c, err: err}
+}
+
+func (c *disabledCollector) Collect(context.Context, Sink) error {
+	return c.err
+}
@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/analyze"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"github.com/steadybit/steadybit-debug/collector"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/diff"
+	"github.com/steadybit/steadybit-debug/output"
+	"github.com/steadybit/steadybit-debug/preflight"
+	"github.com/steadybit/steadybit-debug/versions"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var commands = []*config.Command{
+	{
+		Name:             "list-collectors",
+		ShortDescription: "List the available collectors",
+		LongDescription:  "List the available collectors that can be selected via --only and --skip",
+		Run:              listCollectors,
+	},
+	{
+		Name:             "preflight",
+		ShortDescription: "Check the Kubernetes permissions of the selected collectors",
+		LongDescription:  "Check via SelfSubjectAccessReview whether the current user has all Kubernetes permissions the selected collectors need. Fails if a collector lacks a required permission.",
+		Run:              runPreflightCommand,
+	},
+	{
+		Name:             "decrypt",
+		ShortDescription: "Decrypt an archive created with --encrypt-to",
+		LongDescription:  "Decrypt an archive created with --encrypt-to: decrypt -i <identity file> <archive.tar.gz.age> [<target>]. The target defaults to the archive name without .age.",
+		Options:          &decryptOptions,
+		Run:              decrypt,
+	},
+	{
+		Name:             "join",
+		ShortDescription: "Verify and reassemble an archive split with --split-size",
+		LongDescription:  "Verify the checksums of all chunks listed in the index of an archive split with --split-size and reassemble it: join <archive.tar.gz.index.json> [<target>]. The target defaults to the index name without .index.json.",
+		Run:              join,
+	},
+	{
+		Name:             "analyze",
+		ShortDescription: "Check a bundle for known problems",
+		LongDescription:  "Check a bundle for known problems, e.g. crash-looping pods or failed connection tests: analyze <bundle.tar.gz|bundle.zip|directory>. Additional pattern rules can be loaded via --rules. Fails if a finding has severity error.",
+		Options:          &analyzeOptions,
+		Run:              runAnalyze,
+	},
+	{
+		Name:             "diff",
+		ShortDescription: "Compare two bundles",
+		LongDescription:  "Compare two bundles, e.g. from before and after a setup stopped working: diff <a> <b>. Reports added, removed and modified components, versions, images, environment variables, restarts, actions and target counts.",
+		Options:          &diffOptions,
+		Run:              runDiff,
+	},
+	{
+		Name:             "versions",
+		ShortDescription: "List the component versions of a bundle and check their compatibility",
+		LongDescription:  "List the versions, image tags and digests and the advertised kit API versions of the platform, the agent and the extensions of a bundle: versions <bundle>. With --compat-file, the versions are checked against the compatibility rules in the file. Fails if a rule with severity error is violated.",
+		Options:          &versionsOptions,
+		Run:              runVersions,
+	},
+}
+
+var decryptOptions struct {
+	Identity string `short:"i" long:"identity" required:"true" description:"File with the age private key(s) (AGE-SECRET-KEY-1...)"`
+}
+
+var analyzeOptions struct {
+	Rules       []string `long:"rules" description:"YAML file with additional pattern rules, see the README. Can be repeated."`
+	Format      string   `long:"format" choice:"text" choice:"json" default:"text" description:"Output format of the findings"`
+	MinSeverity string   `long:"min-severity" choice:"info" choice:"warning" choice:"error" default:"info" description:"Only report findings of at least this severity"`
+	ListRules   bool     `long:"list-rules" description:"List the available rules instead of analyzing a bundle"`
+}
+
+var diffOptions struct {
+	Format string `long:"format" choice:"text" choice:"json" default:"text" description:"Output format of the report"`
+}
+
+var versionsOptions struct {
+	Format string `long:"format" choice:"text" choice:"json" default:"text" description:"Output format of the report"`
+}
+
+func listCollectors(_ *config.Config, _ []string) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tDEPENDENCIES\tDESCRIPTION")
+	for _, c := range collector.All() {
+		dependencies := "-"
+		if len(c.Dependencies()) > 0 {
+			dependencies = strings.Join(c.Dependencies(), ",")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name(), dependencies, c.Description())
+	}
+	return tw.Flush()
+}
+
+func runPreflightCommand(cfg *config.Config, _ []string) error {
+	collectors, err := collector.Select(cfg.Only, cfg.Skip)
+	if err != nil {
+		return err
+	}
+	result, err := preflight.Run(context.Background(), cfg, collectors)
+	if err != nil {
+		return err
+	}
+	if err = result.WriteReport(os.Stdout); err != nil {
+		return err
+	}
+	if len(result.Missing) > 0 {
+		return fmt.Errorf("%d of %d collectors lack required permissions and would be disabled", len(result.Missing), len(collectors))
+	}
+	return nil
+}
+
+func decrypt(_ *config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("expected the encrypted archive and optionally the target file, got %d arguments", len(args))
+	}
+	target := output.DecryptedFileName(args[0])
+	if len(args) == 2 {
+		target = args[1]
+	}
+	err := output.DecryptFile(decryptOptions.Identity, args[0], target)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Decrypted %s to %s\n", args[0], target)
+	return nil
+}
+
+func join(_ *config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("expected the index of the chunks and optionally the target file, got %d arguments", len(args))
+	}
+	target := output.JoinedFileName(args[0])
+	if len(args) == 2 {
+		target = args[1]
+	}
+	err := output.JoinChunks(args[0], target)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Verified and joined the chunks of %s to %s\n", args[0], target)
+	return nil
+}
+
+func runAnalyze(_ *config.Config, args []string) error {
+	for _, file := range analyzeOptions.Rules {
+		if err := analyze.LoadRules(file); err != nil {
+			return err
+		}
+	}
+	if analyzeOptions.ListRules {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tDESCRIPTION")
+		for _, r := range analyze.All() {
+			fmt.Fprintf(tw, "%s\t%s\n", r.Name(), r.Description())
+		}
+		return tw.Flush()
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("expected the bundle to analyze, got %d arguments", len(args))
+	}
+	minSeverity, err := analyze.ParseSeverity(analyzeOptions.MinSeverity)
+	if err != nil {
+		return err
+	}
+	b, err := bundle.Load(args[0])
+	if err != nil {
+		return err
+	}
+	findings := analyze.Run(b, minSeverity)
+
+	if analyzeOptions.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		err = encoder.Encode(struct {
+			Bundle   string            `json:"bundle"`
+			Findings []analyze.Finding `json:"findings"`
+		}{b.Name, append([]analyze.Finding{}, findings...)})
+	} else {
+		err = writeFindings(findings)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, finding := range findings {
+		if finding.Severity == analyze.SeverityError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d findings have severity error", failed, len(findings))
+	}
+	return nil
+}
+
+func writeFindings(findings []analyze.Finding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(os.Stdout, "No findings")
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tRULE\tFILE\tMESSAGE")
+	for _, finding := range findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", finding.Severity, finding.Rule, location, finding.Message)
+	}
+	return tw.Flush()
+}
+
+func runDiff(_ *config.Config, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected the two bundles to compare, got %d arguments", len(args))
+	}
+	var snapshots []*diff.Snapshot
+	for _, arg := range args {
+		b, err := bundle.Load(arg)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, diff.NewSnapshot(b))
+	}
+	report := diff.Compare(snapshots[0], snapshots[1])
+	if diffOptions.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(report)
+	}
+	return report.Write(os.Stdout)
+}
+
+func runVersions(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected the bundle, got %d arguments", len(args))
+	}
+	if err := versions.ConfigureCompatibility(cfg.CompatFile); err != nil {
+		return err
+	}
+	b, err := bundle.Load(args[0])
+	if err != nil {
+		return err
+	}
+	report := versions.NewReport(b)
+	if versionsOptions.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		err = encoder.Encode(report)
+	} else {
+		err = report.Write(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	for _, incompatibility := range report.Incompatibilities {
+		if incompatibility.Severity == "error" {
+			return fmt.Errorf("%d incompatibilities found", len(report.Incompatibilities))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes that can be configured as "500MB", "2GiB" or plain bytes via command-line flags and
+// the configuration file. Decimal (KB, MB, GB) and binary (KiB, MiB, GiB) units are supported.
+type ByteSize int64
+
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// longest suffixes first, so that "MiB" isn't parsed as "B"
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000}, {"TB", 1000 * 1000 * 1000 * 1000},
+	{"K", 1000}, {"M", 1000 * 1000}, {"G", 1000 * 1000 * 1000}, {"T", 1000 * 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+func (b ByteSize) String() string {
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"GB", 1000 * 1000 * 1000}, {"MB", 1000 * 1000}, {"KB", 1000}} {
+		if int64(b) >= unit.multiplier {
+			return strconv.FormatFloat(float64(b)/float64(unit.multiplier), 'f', -1, 64) + unit.suffix
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
+
+func (b ByteSize) MarshalFlag() (string, error) {
+	return b.String(), nil
+}
+
+func (b *ByteSize) UnmarshalFlag(value string) error {
+	value = strings.TrimSpace(value)
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(strings.ToUpper(value), strings.ToUpper(unit.suffix)) {
+			value = strings.TrimSpace(value[:len(value)-len(unit.suffix)])
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("invalid size '%s', expected e.g. 500MB or 2GiB", value)
+	}
+	*b = ByteSize(parsed * float64(multiplier))
+	return nil
+}
+
+func (b ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case string:
+		return b.UnmarshalFlag(v)
+	case float64:
+		*b = ByteSize(v)
+		return nil
+	default:
+		return fmt.Errorf("invalid size %s", string(data))
+	}
+}
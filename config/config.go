@@ -4,14 +4,10 @@
 package config
 
 import (
-	"errors"
-	"flag"
+	"fmt"
 	"github.com/jessevdk/go-flags"
 	"github.com/rs/zerolog/log"
 	"k8s.io/apimachinery/pkg/util/yaml"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 	"os"
 	"path/filepath"
@@ -19,13 +15,34 @@ import (
 )
 
 type Config struct {
-	OutputPath           string                     `yaml:"outputPath" short:"o" long:"output" description:"Path to output directory that will contain the debugging information"`
+	OutputPath           string                     `yaml:"outputPath" short:"o" long:"output" description:"Path to output directory that will contain the debugging information, or - to write the archive to stdout"`
 	NoCleanup            bool                       `yaml:"noCleanup" long:"no-cleanup" description:"Skip output directory deletion on command completion?"`
+	Only                 []string                   `yaml:"only" long:"only" description:"Run only the given collectors, see list-collectors (repeatable or comma-separated)"`
+	Skip                 []string                   `yaml:"skip" long:"skip" description:"Skip the given collectors, see list-collectors (repeatable or comma-separated)"`
+	DryRun               bool                       `yaml:"dryRun" long:"dry-run" description:"Only discover the workloads and print what would be collected, without executing commands, port-forwards or requests"`
+	PlanFormat           string                     `yaml:"planFormat" long:"plan-format" choice:"tree" choice:"json" description:"Format of the --dry-run plan"`
+	NoPreflight          bool                       `yaml:"noPreflight" long:"no-preflight" description:"Skip the check of Kubernetes permissions before collection, see the preflight command"`
+	ReadOnly             bool                       `yaml:"readOnly" long:"read-only" description:"Never modify cluster objects. Connection tests that need ephemeral containers are skipped"`
+	Timeout              Duration                   `yaml:"timeout" long:"timeout" description:"Maximum duration of the whole run, e.g. 15m. A partial bundle is written when it expires (0 = no limit)"`
+	CollectorTimeouts    map[string]Duration        `yaml:"collectorTimeouts" long:"collector-timeout" description:"Maximum duration of a single collector as name:duration, e.g. agent:5m (repeatable)"`
+	Anonymize            bool                       `yaml:"anonymize" long:"anonymize" description:"Consistently replace node names, IPs, cluster domains, namespaces and platform hostnames with tokens"`
+	AnonymizeMapping     string                     `yaml:"anonymizeMapping" long:"anonymize-mapping" description:"File to store the token mapping in, it is never archived (default: steadybit-debug-anonymization.json in the output directory)"`
+	ArchiveFormat        string                     `yaml:"archiveFormat" long:"format" choice:"tar.gz" choice:"zip" description:"Format of the archive"`
+	Stream               bool                       `yaml:"stream" long:"stream" description:"Write collected files straight into the archive instead of an output directory. Not supported with --anonymize and --max-bundle-size"`
+	SplitSize            ByteSize                   `yaml:"splitSize" long:"split-size" description:"Split the archive into numbered chunks of at most this size, e.g. 100MB, plus an index with checksums, see the join command"`
+	EncryptTo            string                     `yaml:"encryptTo" long:"encrypt-to" description:"Encrypt the archive for the age public keys (age1...) in the given file, see the decrypt command"`
 	Kubernetes           KubernetesConfig           `yaml:"kubernetes"`
 	Platform             PlatformConfig             `yaml:"platform"`
 	PlatformPortSplitter PlatformportSplitterConfig `yaml:"platform-port-splitter"`
 	Agent                AgentConfig                `yaml:"agent"`
 	Tls                  Tls                        `yaml:"tls"`
+	Parallelism          ParallelismConfig          `yaml:"parallelism"`
+	Redaction            RedactionConfig            `yaml:"redaction"`
+	Logs                 LogsConfig                 `yaml:"logs"`
+	Upload               UploadConfig               `yaml:"upload"`
+	ThreadDumps          ThreadDumpConfig           `yaml:"threadDumps"`
+	MaxBundleSize        ByteSize                   `yaml:"maxBundleSize" long:"max-bundle-size" description:"Maximum size of the collected files, e.g. 500MB. The biggest logs are shortened first (0 = no limit)"`
+	CompatFile           string                     `yaml:"compatFile" long:"compat-file" description:"YAML file with version compatibility rules that versions.json is checked against, see the versions command"`
 }
 
 type PlatformConfig struct {
@@ -45,6 +62,7 @@ type AgentConfig struct {
 	CurlImage       string `yaml:"curlImage" long:"agent-curl-image" description:"Image to use for connection testing with curl installed"`
 	WebsocatImage   string `yaml:"websocatImage" long:"agent-websocat-image" description:"Image to use for connection testing with websocat installed"`
 	TracerouteImage string `yaml:"tracerouteImage" long:"agent-traceroute-image" description:"Image to use for connection testing with traceroute installed"`
+	ProbeMode       string `yaml:"probeMode" long:"agent-probe-mode" choice:"ephemeral-container" choice:"probe-pod" description:"Run connection tests in ephemeral containers of the agent pods or in short-lived probe pods next to them"`
 }
 
 type Tls struct {
@@ -52,49 +70,107 @@ type Tls struct {
 	CertKeyFile   string `yaml:"certKeyFile" long:"cert-key-file" description:"Path to the certificate key file"`
 }
 
-type KubernetesConfig struct {
-	KubeConfigPath string `yaml:"kubeConfigPath" long:"kube-config" description:"Path to Kubernetes config"`
+type ParallelismConfig struct {
+	Default   int `yaml:"default" long:"parallelism" description:"Maximum number of concurrent Kubernetes API requests, subprocesses and HTTP requests (each)"`
+	Api       int `yaml:"api" long:"parallelism-api" description:"Maximum number of concurrent Kubernetes API requests (defaults to --parallelism)"`
+	Processes int `yaml:"processes" long:"parallelism-processes" description:"Maximum number of concurrent subprocesses like kubectl or curl (defaults to --parallelism)"`
+	Http      int `yaml:"http" long:"parallelism-http" description:"Maximum number of concurrent HTTP requests to platform, agent and extensions (defaults to --parallelism)"`
 }
 
-func (c KubernetesConfig) Client() (*kubernetes.Clientset, error) {
-	config, err := rest.InClusterConfig()
-	if err == nil {
-		log.Debug().Msgf("Steadybit-Debug is running inside a cluster, config found")
-	} else if errors.Is(err, rest.ErrNotInCluster) {
-		log.Debug().Msgf("Steadybit-Debug is not running inside a cluster, try local .kube config")
-		var kubeconfig *string
-		// use the current context in kubeconfig
-		if home := homedir.HomeDir(); home != "" {
-			config, err = clientcmd.BuildConfigFromFlags("", c.KubeConfigPath)
-		} else {
-			kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-			flag.Parse()
-			config, err = clientcmd.BuildConfigFromFlags("", *kubeconfig)
-		}
-	}
+type RedactionConfig struct {
+	Disabled bool     `yaml:"disabled" long:"no-redaction" description:"Do not mask secrets in the collected artifacts"`
+	Keys     []string `yaml:"keys" long:"redact-key" description:"Regular expression for additional keys whose values are masked, e.g. (?i)customer_id (repeatable)"`
+	Patterns []string `yaml:"patterns" long:"redact-pattern" description:"Regular expression for additional values that are masked wherever they appear (repeatable)"`
+}
 
-	if err != nil {
-		log.Debug().Err(err).Msgf("Could not find kubernetes config")
-		return nil, err
-	}
+type UploadConfig struct {
+	Url       string   `yaml:"url" long:"upload-url" description:"Upload the archive to this URL, e.g. an S3-style presigned URL"`
+	Method    string   `yaml:"method" long:"upload-method" choice:"PUT" choice:"POST" description:"PUT sends the archive as request body, POST as multipart form"`
+	FormField string   `yaml:"formField" long:"upload-form-field" description:"Name of the multipart form field for POST uploads"`
+	Headers   []string `yaml:"headers" long:"upload-header" description:"Additional header of the upload as 'Name: value', e.g. for authorization (repeatable)"`
+	Retries   int      `yaml:"retries" long:"upload-retries" description:"Number of retries of a failed upload"`
+}
 
-	config.UserAgent = "steadybit-debug"
-	config.Timeout = time.Second * 10
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		log.Debug().Err(err).Msgf("Could not create kubernetes client")
-		return nil, err
+type ThreadDumpConfig struct {
+	Count    int      `yaml:"count" long:"thread-dumps" description:"Number of thread dumps taken of each platform and agent pod. Several dumps are compared for stuck threads"`
+	Interval Duration `yaml:"interval" long:"thread-dump-interval" description:"Delay between the thread dumps of a pod, e.g. 10s"`
+}
+
+type LogsConfig struct {
+	Since     Duration  `yaml:"since" long:"since" description:"Only collect log lines newer than a relative duration like 2h"`
+	SinceTime Timestamp `yaml:"sinceTime" long:"since-time" description:"Only collect log lines after a time in RFC 3339 format, e.g. 2026-01-02T15:04:05Z"`
+	Until     Timestamp `yaml:"until" long:"until" description:"Only collect log lines before a time in RFC 3339 format"`
+	Tail      int64     `yaml:"tail" long:"tail" description:"Only collect the given number of most recent log lines per container (0 = all)"`
+	// Collectors overrides the log window per collector, e.g. 'agent' or 'extensions'
+	Collectors map[string]LogWindow `yaml:"collectors"`
+}
+
+// LogWindow limits the collected log lines. Zero values mean no limit.
+type LogWindow struct {
+	Since     Duration  `yaml:"since"`
+	SinceTime Timestamp `yaml:"sinceTime"`
+	Until     Timestamp `yaml:"until"`
+	// Tail is nil in overrides that inherit the global tail, so that they can reset it to 0
+	Tail *int64 `yaml:"tail"`
+}
+
+// TailLines returns the number of most recent lines to collect, 0 for all
+func (w LogWindow) TailLines() int64 {
+	if w.Tail == nil {
+		return 0
 	}
+	return *w.Tail
+}
 
-	info, err := clientset.ServerVersion()
-	if err != nil {
-		log.Debug().Err(err).Msgf("Could not fetch server version.")
-		return nil, err
+// Validate rejects windows that kubectl would reject as well, e.g. both --since and --since-time
+func (c LogsConfig) Validate() error {
+	windows := map[string]LogWindow{"": {Since: c.Since, SinceTime: c.SinceTime, Until: c.Until, Tail: &c.Tail}}
+	for collector, window := range c.Collectors {
+		windows[collector] = window
+	}
+	for collector, window := range windows {
+		prefix := ""
+		if collector != "" {
+			prefix = fmt.Sprintf("collector '%s': ", collector)
+		}
+		if window.Since != 0 && !window.SinceTime.IsZero() {
+			return fmt.Errorf("%sonly one of since and since-time may be set", prefix)
+		}
+		if window.Since < 0 || window.TailLines() < 0 {
+			return fmt.Errorf("%ssince and tail must not be negative", prefix)
+		}
+		if !window.SinceTime.IsZero() && !window.Until.IsZero() && !window.Until.Time().After(window.SinceTime.Time()) {
+			return fmt.Errorf("%suntil must be after since-time", prefix)
+		}
 	}
+	return nil
+}
 
-	log.Debug().Msgf("Cluster connected! Kubernetes Server Version %+v", info)
+// Window returns the log window of the given collector, its overrides take precedence over the global settings
+func (c LogsConfig) Window(collector string) LogWindow {
+	tail := c.Tail
+	window := LogWindow{Since: c.Since, SinceTime: c.SinceTime, Until: c.Until, Tail: &tail}
+	override, ok := c.Collectors[collector]
+	if !ok {
+		return window
+	}
+	if override.Since != 0 || !override.SinceTime.IsZero() {
+		window.Since, window.SinceTime = override.Since, override.SinceTime
+	}
+	if !override.Until.IsZero() {
+		window.Until = override.Until
+	}
+	if override.Tail != nil {
+		tail = *override.Tail
+	}
+	return window
+}
 
-	return clientset, nil
+type KubernetesConfig struct {
+	KubeConfigPath string   `yaml:"kubeConfigPath" long:"kube-config" description:"Path to Kubernetes config"`
+	QPS            float32  `yaml:"qps" long:"kube-api-qps" description:"Maximum number of requests per second against the Kubernetes API"`
+	Burst          int      `yaml:"burst" long:"kube-api-burst" description:"Maximum burst of requests against the Kubernetes API"`
+	RequestTimeout Duration `yaml:"requestTimeout" long:"kube-api-timeout" description:"Timeout of a single (non-streaming) Kubernetes API request"`
 }
 
 func newConfig() Config {
@@ -110,10 +186,24 @@ func newConfig() Config {
 	}
 
 	return Config{
-		OutputPath: outputPath,
-		NoCleanup:  false,
+		OutputPath:    outputPath,
+		NoCleanup:     false,
+		PlanFormat:    "tree",
+		ArchiveFormat: "tar.gz",
+		Upload: UploadConfig{
+			Method:    "PUT",
+			FormField: "file",
+			Retries:   3,
+		},
+		ThreadDumps: ThreadDumpConfig{
+			Count:    1,
+			Interval: Duration(5 * time.Second),
+		},
 		Kubernetes: KubernetesConfig{
 			KubeConfigPath: kubeConfigPath,
+			QPS:            20,
+			Burst:          40,
+			RequestTimeout: Duration(10 * time.Second),
 		},
 		Platform: PlatformConfig{
 			Namespace:      "steadybit-platform",
@@ -130,11 +220,15 @@ func newConfig() Config {
 			CurlImage:       "curlimages/curl",
 			WebsocatImage:   "mtilson/websocat",
 			TracerouteImage: "alpine",
+			ProbeMode:       "ephemeral-container",
 		},
 		Tls: Tls{
 			CertChainFile: "",
 			CertKeyFile:   "",
 		},
+		Parallelism: ParallelismConfig{
+			Default: 10,
+		},
 	}
 }
 
@@ -162,13 +256,50 @@ func loadConfig() Config {
 	return config
 }
 
-func GetConfig() Config {
+// Command is a subcommand that is executed instead of the collection run
+type Command struct {
+	Name             string
+	ShortDescription string
+	LongDescription  string
+	// Options is an optional pointer to a struct with go-flags tags for command specific options
+	Options any
+	Run     func(cfg *Config, args []string) error
+}
+
+// GetConfig loads the configuration file and parses the command-line arguments. The returned command is nil
+// when none of the given commands was invoked.
+func GetConfig(commands ...*Command) (Config, *Command, []string) {
 	config := loadConfig()
 
-	_, err := flags.Parse(&config)
+	parser := flags.NewParser(&config, flags.Default)
+	parser.SubcommandsOptional = true
+	// commands are executed by the caller once parsing completed
+	parser.CommandHandler = func(flags.Commander, []string) error {
+		return nil
+	}
+	for _, command := range commands {
+		options := command.Options
+		if options == nil {
+			options = &struct{}{}
+		}
+		_, err := parser.AddCommand(command.Name, command.ShortDescription, command.LongDescription, options)
+		if err != nil {
+			log.Err(err).Msgf("Failed to register command '%s'", command.Name)
+			os.Exit(1)
+		}
+	}
+
+	args, err := parser.Parse()
 	if err != nil {
 		os.Exit(1)
 	}
 
-	return config
+	if parser.Active != nil {
+		for _, command := range commands {
+			if command.Name == parser.Active.Name {
+				return config, command, args
+			}
+		}
+	}
+	return config, nil, args
 }
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogsConfigWindowTail(t *testing.T) {
+	zero, thousand := int64(0), int64(1000)
+	logs := LogsConfig{
+		Tail: 500,
+		Collectors: map[string]LogWindow{
+			"agent":      {Tail: &zero},
+			"extensions": {Tail: &thousand},
+			"platform":   {Since: Duration(time.Hour)},
+		},
+	}
+
+	tests := []struct {
+		collector string
+		want      int64
+	}{
+		{collector: "nodes", want: 500},
+		{collector: "agent", want: 0},
+		{collector: "extensions", want: 1000},
+		{collector: "platform", want: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collector, func(t *testing.T) {
+			if got := logs.Window(tt.collector).TailLines(); got != tt.want {
+				t.Errorf("got tail %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
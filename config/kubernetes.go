@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package config
+
+import (
+	"errors"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/scheduler"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+	"net/http"
+	"sync"
+)
+
+// kubernetesConnection holds the client shared by all collectors. It is created lazily on first use.
+type kubernetesConnection struct {
+	once            sync.Once
+	restConfig      *rest.Config
+	httpClient      *http.Client
+	client          *kubernetes.Clientset
+	streamingClient *kubernetes.Clientset
+	err             error
+}
+
+var (
+	kubernetesConnectionsMu sync.Mutex
+	kubernetesConnections   = map[KubernetesConfig]*kubernetesConnection{}
+)
+
+func (c KubernetesConfig) connection() *kubernetesConnection {
+	kubernetesConnectionsMu.Lock()
+	conn, ok := kubernetesConnections[c]
+	if !ok {
+		conn = &kubernetesConnection{}
+		kubernetesConnections[c] = conn
+	}
+	kubernetesConnectionsMu.Unlock()
+
+	conn.once.Do(func() {
+		conn.err = conn.connect(c)
+	})
+	return conn
+}
+
+func (conn *kubernetesConnection) connect(c KubernetesConfig) error {
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		log.Debug().Msgf("Steadybit-Debug is running inside a cluster, config found")
+	} else if errors.Is(err, rest.ErrNotInCluster) {
+		log.Debug().Msgf("Steadybit-Debug is not running inside a cluster, try local .kube config")
+		config, err = clientcmd.BuildConfigFromFlags("", c.KubeConfigPath)
+	}
+
+	if err != nil {
+		log.Debug().Err(err).Msgf("Could not find kubernetes config")
+		return err
+	}
+
+	config.UserAgent = "steadybit-debug"
+	config.Timeout = c.RequestTimeout.Duration()
+	config.QPS = c.QPS
+	config.Burst = c.Burst
+	// a single rate limiter for all clients derived from this config, so that QPS and burst apply to the whole run
+	config.RateLimiter = &instrumentedRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(c.QPS, c.Burst),
+		stats:       kubernetesApiStats,
+	}
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return &limitedRoundTripper{delegate: &instrumentedRoundTripper{delegate: rt, stats: kubernetesApiStats}}
+	}
+
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Could not create kubernetes http client")
+		return err
+	}
+
+	client, err := kubernetes.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Could not create kubernetes client")
+		return err
+	}
+
+	// streaming requests like logs must not be cut off by the request timeout, but can share the transport
+	streamingConfig := rest.CopyConfig(config)
+	streamingConfig.Timeout = 0
+	streamingClient, err := kubernetes.NewForConfigAndClient(streamingConfig, &http.Client{Transport: httpClient.Transport})
+	if err != nil {
+		log.Debug().Err(err).Msgf("Could not create kubernetes client")
+		return err
+	}
+
+	info, err := client.ServerVersion()
+	if err != nil {
+		log.Debug().Err(err).Msgf("Could not fetch server version.")
+		return err
+	}
+
+	log.Debug().Msgf("Cluster connected! Kubernetes Server Version %+v", info)
+
+	conn.restConfig = config
+	conn.httpClient = httpClient
+	conn.client = client
+	conn.streamingClient = streamingClient
+	return nil
+}
+
+// limitedRoundTripper bounds the number of concurrent API requests. Streamed response bodies don't hold a slot.
+type limitedRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (rt *limitedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := scheduler.Acquire(req.Context(), scheduler.Api)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return rt.delegate.RoundTrip(req)
+}
+
+func (rt *limitedRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
+
+// RestConfig returns a copy of the shared rest config. Clients created from it share the rate limiter and instrumentation.
+func (c KubernetesConfig) RestConfig() (*rest.Config, error) {
+	conn := c.connection()
+	if conn.err != nil {
+		return nil, conn.err
+	}
+	return rest.CopyConfig(conn.restConfig), nil
+}
+
+// HttpClient returns the shared http client to create additional API clients via their NewForConfigAndClient functions
+func (c KubernetesConfig) HttpClient() (*http.Client, error) {
+	conn := c.connection()
+	return conn.httpClient, conn.err
+}
+
+// Client returns the shared Kubernetes client
+func (c KubernetesConfig) Client() (*kubernetes.Clientset, error) {
+	conn := c.connection()
+	return conn.client, conn.err
+}
+
+// StreamingClient returns a shared Kubernetes client without request timeout for long-running requests like log streams
+func (c KubernetesConfig) StreamingClient() (*kubernetes.Clientset, error) {
+	conn := c.connection()
+	return conn.streamingClient, conn.err
+}
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"k8s.io/client-go/util/flowcontrol"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientSideThrottlingThreshold is the rate limiter wait time from which on a request counts as throttled
+const clientSideThrottlingThreshold = 10 * time.Millisecond
+
+type KubernetesApiStatistics struct {
+	TotalRequests        int                              `json:"totalRequests"`
+	FailedRequests       int                              `json:"failedRequests"`
+	ServerSideThrottled  int                              `json:"serverSideThrottled"`
+	ClientSideThrottling KubernetesApiThrottlingStatistic `json:"clientSideThrottling"`
+	Requests             []KubernetesApiRequestStatistic  `json:"requests"`
+}
+
+type KubernetesApiThrottlingStatistic struct {
+	ThrottledRequests int      `json:"throttledRequests"`
+	TotalWait         Duration `json:"totalWait"`
+	MaxWait           Duration `json:"maxWait"`
+}
+
+// KubernetesApiRequestStatistic latencies are measured until the response headers arrived, i.e. streamed bodies are not included
+type KubernetesApiRequestStatistic struct {
+	Request        string         `json:"request"`
+	Count          int            `json:"count"`
+	Errors         int            `json:"errors"`
+	StatusCodes    map[string]int `json:"statusCodes"`
+	TotalLatency   Duration       `json:"totalLatency"`
+	AverageLatency Duration       `json:"averageLatency"`
+	P95Latency     Duration       `json:"p95Latency"`
+	MaxLatency     Duration       `json:"maxLatency"`
+}
+
+type apiStats struct {
+	mu        sync.Mutex
+	requests  map[string]*apiRequestStats
+	waits     int
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+type apiRequestStats struct {
+	errors      int
+	statusCodes map[string]int
+	latencies   []time.Duration
+}
+
+var kubernetesApiStats = &apiStats{requests: map[string]*apiRequestStats{}}
+
+func (s *apiStats) recordRequest(request string, statusCode int, err error, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.requests[request]
+	if !ok {
+		stats = &apiRequestStats{statusCodes: map[string]int{}}
+		s.requests[request] = stats
+	}
+	stats.latencies = append(stats.latencies, latency)
+	if err != nil {
+		stats.errors++
+		stats.statusCodes["error"]++
+	} else {
+		stats.statusCodes[fmt.Sprintf("%d", statusCode)]++
+	}
+}
+
+func (s *apiStats) recordWait(wait time.Duration) {
+	if wait < clientSideThrottlingThreshold {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.waits++
+	s.totalWait += wait
+	if wait > s.maxWait {
+		s.maxWait = wait
+	}
+}
+
+// KubernetesApiStats returns the request counts and latencies of all Kubernetes API requests of this run so far
+func KubernetesApiStats() KubernetesApiStatistics {
+	s := kubernetesApiStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := KubernetesApiStatistics{
+		ClientSideThrottling: KubernetesApiThrottlingStatistic{
+			ThrottledRequests: s.waits,
+			TotalWait:         Duration(s.totalWait),
+			MaxWait:           Duration(s.maxWait),
+		},
+		Requests: make([]KubernetesApiRequestStatistic, 0, len(s.requests)),
+	}
+	for request, stats := range s.requests {
+		latencies := append([]time.Duration(nil), stats.latencies...)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, latency := range latencies {
+			total += latency
+		}
+		statusCodes := make(map[string]int, len(stats.statusCodes))
+		for code, count := range stats.statusCodes {
+			statusCodes[code] = count
+		}
+
+		result.TotalRequests += len(latencies)
+		result.FailedRequests += stats.errors
+		result.ServerSideThrottled += stats.statusCodes["429"]
+		result.Requests = append(result.Requests, KubernetesApiRequestStatistic{
+			Request:        request,
+			Count:          len(latencies),
+			Errors:         stats.errors,
+			StatusCodes:    statusCodes,
+			TotalLatency:   Duration(total),
+			AverageLatency: Duration(total / time.Duration(len(latencies))),
+			P95Latency:     Duration(latencies[(len(latencies)*95-1)/100]),
+			MaxLatency:     Duration(latencies[len(latencies)-1]),
+		})
+	}
+	sort.Slice(result.Requests, func(i, j int) bool {
+		return result.Requests[i].TotalLatency > result.Requests[j].TotalLatency
+	})
+	return result
+}
+
+type instrumentedRoundTripper struct {
+	delegate http.RoundTripper
+	stats    *apiStats
+}
+
+func (rt *instrumentedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.delegate.RoundTrip(req)
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	rt.stats.recordRequest(describeApiRequest(req), statusCode, err, time.Since(start))
+	return resp, err
+}
+
+func (rt *instrumentedRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
+
+// describeApiRequest condenses a request into verb and resource, e.g. "list pods", "get pods/log" or "get deployments.apps"
+func describeApiRequest(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	group := ""
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		group = segments[1]
+		segments = segments[3:]
+	default:
+		return fmt.Sprintf("%s %s", strings.ToLower(req.Method), req.URL.Path)
+	}
+
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		segments = segments[2:]
+	}
+
+	resource := ""
+	named := false
+	if len(segments) > 0 {
+		resource = segments[0]
+		if group != "" {
+			resource = resource + "." + group
+		}
+	}
+	if len(segments) > 1 {
+		named = true
+	}
+	if len(segments) > 2 {
+		resource = resource + "/" + segments[2]
+	}
+
+	verb := strings.ToLower(req.Method)
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" {
+			verb = "watch"
+		} else if named {
+			verb = "get"
+		} else {
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	}
+	return fmt.Sprintf("%s %s", verb, resource)
+}
+
+type instrumentedRateLimiter struct {
+	flowcontrol.RateLimiter
+	stats *apiStats
+}
+
+func (l *instrumentedRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	l.stats.recordWait(time.Since(start))
+	return err
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package config
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Timestamp is a point in time that can be configured in RFC 3339 format, e.g. "2026-01-02T15:04:05Z", via
+// command-line flags and the configuration file
+type Timestamp time.Time
+
+func (t Timestamp) Time() time.Time {
+	return time.Time(t)
+}
+
+func (t Timestamp) IsZero() bool {
+	return time.Time(t).IsZero()
+}
+
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return time.Time(t).Format(time.RFC3339)
+}
+
+func (t Timestamp) MarshalFlag() (string, error) {
+	return t.String(), nil
+}
+
+func (t *Timestamp) UnmarshalFlag(value string) error {
+	if value == "" {
+		*t = Timestamp{}
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return err
+	}
+	*t = Timestamp(parsed)
+	return nil
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return t.UnmarshalFlag(value)
+}
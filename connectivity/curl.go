@@ -0,0 +1,448 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+// Package connectivity interprets the verbose output of the curl connection tests
+package connectivity
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Stage string
+
+const (
+	StageDns       Stage = "dns"
+	StageTcp       Stage = "tcp"
+	StageProxy     Stage = "proxy"
+	StageTls       Stage = "tls"
+	StageHttp      Stage = "http"
+	StageWebsocket Stage = "websocket"
+)
+
+type StageResult struct {
+	Stage  Stage  `json:"stage"`
+	Ok     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Tls struct {
+	Protocol     string `json:"protocol,omitempty"`
+	Cipher       string `json:"cipher,omitempty"`
+	Subject      string `json:"subject,omitempty"`
+	Issuer       string `json:"issuer,omitempty"`
+	VerifyResult string `json:"verifyResult,omitempty"`
+}
+
+// Verdict is the interpretation of a connection test. Stages lists the stages that were reached in order, the last
+// one failed unless Ok.
+type Verdict struct {
+	Ok          bool          `json:"ok"`
+	FailedStage Stage         `json:"failedStage,omitempty"`
+	Cause       string        `json:"cause,omitempty"`
+	Stages      []StageResult `json:"stages"`
+	Host        string        `json:"host,omitempty"`
+	Port        int           `json:"port,omitempty"`
+	Proxy       string        `json:"proxy,omitempty"`
+	ProxyStatus int           `json:"proxyStatus,omitempty"`
+	Tls         *Tls          `json:"tls,omitempty"`
+	HttpStatus  int           `json:"httpStatus,omitempty"`
+	// Websocket is set if the test requested a websocket upgrade
+	Websocket    bool   `json:"websocket,omitempty"`
+	CurlExitCode int    `json:"curlExitCode,omitempty"`
+	CurlError    string `json:"curlError,omitempty"`
+}
+
+var (
+	curlErrorPattern    = regexp.MustCompile(`^curl: \((\d+)\) (.*)$`)
+	resolvedPattern     = regexp.MustCompile(`^\* Host (\S+):(\d+) was resolved`)
+	tryingPattern       = regexp.MustCompile(`^\*\s+Trying \[?([^\s\]]+?)\]?:(\d+)`)
+	connectedPattern    = regexp.MustCompile(`^\* (?:Connected to|Established connection to) (\S+) \(([^)]*)\) port (\d+)`)
+	connectFailPattern  = regexp.MustCompile(`^\* connect to (\S+) port (\d+) .*failed: (.*)$`)
+	failedToPattern     = regexp.MustCompile(`^\* Failed to connect to (\S+) port (\d+)`)
+	proxyEnvPattern     = regexp.MustCompile(`^\* Uses proxy env variable \S+ == '([^']*)'`)
+	connectPattern      = regexp.MustCompile(`^> CONNECT (\S+?):(\d+) `)
+	statusPattern       = regexp.MustCompile(`^< HTTP/[\d.]+ (\d{3})`)
+	sslConnectionPrefix = "* SSL connection using "
+	requestPattern      = regexp.MustCompile(`^> [A-Z]+ \S+ HTTP/`)
+	upgradePattern      = regexp.MustCompile(`(?i)^> upgrade:\s*websocket`)
+	urlHostPattern      = regexp.MustCompile(`(?i)resolve (?:proxy|host): (\S+)`)
+)
+
+// publicIssuers are parts of the names of public certificate authorities. Other issuers of an untrusted certificate
+// usually belong to a TLS-inspecting proxy.
+var publicIssuers = []string{
+	"Let's Encrypt", "ISRG", "DigiCert", "Amazon", "Google Trust Services", "GlobalSign", "Sectigo", "COMODO",
+	"USERTrust", "GoDaddy", "Starfield", "Entrust", "Microsoft", "Cloudflare", "ZeroSSL", "Baltimore", "GeoTrust",
+	"Thawte", "RapidSSL", "IdenTrust", "Buypass", "SSL.com", "Certum", "QuoVadis", "Actalis", "HARICA",
+}
+
+// parser keeps the progress through the stages of a connection while reading the curl output
+type parser struct {
+	verdict    Verdict
+	resolved   bool
+	connected  bool
+	connectIP  string
+	connecting bool
+	// connectError is the reason of the last failed connect attempt, e.g. 'Connection refused'
+	connectError string
+	inConnect    bool
+	tunnel       bool
+	tlsStarted   bool
+	tlsDone      bool
+	verifyOk     *bool
+	tlsProblem   string
+	requested    bool
+}
+
+// ParseCurl interprets the output of 'curl -v'. Lines of other tools, e.g. kubectl, are ignored.
+func ParseCurl(output string) Verdict {
+	p := &parser{verdict: Verdict{Stages: []StageResult{}}}
+	for _, line := range strings.Split(output, "\n") {
+		p.parseLine(strings.TrimRight(line, "\r"))
+	}
+	p.evaluate()
+	return p.verdict
+}
+
+func (p *parser) parseLine(line string) {
+	v := &p.verdict
+	switch {
+	case curlErrorPattern.MatchString(line):
+		m := curlErrorPattern.FindStringSubmatch(line)
+		v.CurlExitCode, _ = strconv.Atoi(m[1])
+		v.CurlError = m[2]
+	case proxyEnvPattern.MatchString(line):
+		v.Proxy = proxyEnvPattern.FindStringSubmatch(line)[1]
+	case resolvedPattern.MatchString(line):
+		m := resolvedPattern.FindStringSubmatch(line)
+		p.resolved = true
+		if v.Proxy == "" {
+			v.Host, v.Port = m[1], atoi(m[2])
+		}
+	case tryingPattern.MatchString(line):
+		p.resolved = true
+		p.connecting = true
+		m := tryingPattern.FindStringSubmatch(line)
+		p.connectIP = m[1]
+		if v.Port == 0 && v.Proxy == "" {
+			v.Port = atoi(m[2])
+		}
+	case connectedPattern.MatchString(line):
+		m := connectedPattern.FindStringSubmatch(line)
+		p.resolved, p.connected = true, true
+		p.connectIP = m[2]
+		if v.Proxy == "" {
+			v.Host, v.Port = m[1], atoi(m[3])
+		}
+	case connectFailPattern.MatchString(line):
+		m := connectFailPattern.FindStringSubmatch(line)
+		p.connecting = true
+		p.connectIP, p.connectError = m[1], m[3]
+	case failedToPattern.MatchString(line):
+		m := failedToPattern.FindStringSubmatch(line)
+		p.connecting = true
+		if v.Proxy == "" {
+			v.Host, v.Port = m[1], atoi(m[2])
+		}
+	case connectPattern.MatchString(line):
+		m := connectPattern.FindStringSubmatch(line)
+		p.inConnect = true
+		v.Host, v.Port = m[1], atoi(m[2])
+	case statusPattern.MatchString(line):
+		status := atoi(statusPattern.FindStringSubmatch(line)[1])
+		if p.inConnect {
+			p.inConnect = false
+			v.ProxyStatus = status
+			p.tunnel = status >= 200 && status < 300
+		} else if p.requested {
+			// later responses, e.g. after a redirect or '100 Continue', replace earlier ones
+			v.HttpStatus = status
+		}
+	case requestPattern.MatchString(line):
+		p.requested = true
+	case upgradePattern.MatchString(line):
+		v.Websocket = true
+	case strings.HasPrefix(line, sslConnectionPrefix):
+		p.tlsStarted, p.tlsDone = true, true
+		parts := strings.Split(strings.TrimPrefix(line, sslConnectionPrefix), " / ")
+		p.tls().Protocol = parts[0]
+		if len(parts) > 1 {
+			p.tls().Cipher = parts[1]
+		}
+	case strings.Contains(line, "TLS handshake") || strings.HasPrefix(line, "* ALPN"):
+		if !p.inConnect {
+			p.tlsStarted = true
+		}
+	case strings.HasPrefix(line, "*  subject: "):
+		p.tls().Subject = strings.TrimPrefix(line, "*  subject: ")
+	case strings.HasPrefix(line, "*  issuer: "):
+		p.tls().Issuer = strings.TrimPrefix(line, "*  issuer: ")
+	case strings.HasPrefix(line, "*  SSL certificate verify ok"):
+		ok := true
+		p.verifyOk = &ok
+		p.tls().VerifyResult = "ok"
+	case strings.HasPrefix(line, "*  SSL certificate verify result: "):
+		ok := false
+		p.verifyOk = &ok
+		p.tls().VerifyResult = strings.TrimSuffix(strings.TrimPrefix(line, "*  SSL certificate verify result: "), ", continuing anyway.")
+	case strings.HasPrefix(line, "* SSL certificate problem: "):
+		p.tlsStarted = true
+		p.tlsProblem = strings.TrimPrefix(line, "* SSL certificate problem: ")
+		ok := false
+		p.verifyOk = &ok
+		p.tls().VerifyResult = p.tlsProblem
+	}
+}
+
+func (p *parser) tls() *Tls {
+	if p.verdict.Tls == nil {
+		p.verdict.Tls = &Tls{}
+	}
+	return p.verdict.Tls
+}
+
+func atoi(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
+
+// evaluate derives the stages and the first failure from what was seen
+func (p *parser) evaluate() {
+	v := &p.verdict
+	code := v.CurlExitCode
+	if code == 0 && !p.resolved && !p.connecting && v.HttpStatus == 0 {
+		v.Cause = "The connection test produced no curl output, it probably didn't run"
+		return
+	}
+
+	// DNS
+	switch {
+	case code == 5:
+		p.fail(StageDns, fmt.Sprintf("DNS lookup of the proxy %s failed, check the proxy host name", hostOf(v.CurlError)))
+		return
+	case code == 6:
+		p.fail(StageDns, fmt.Sprintf("DNS lookup of %s failed, check the host name and the cluster DNS", hostOf(v.CurlError)))
+		return
+	}
+	dnsDetail := "resolved"
+	if p.connectIP != "" {
+		dnsDetail = fmt.Sprintf("resolved to %s", p.connectIP)
+	}
+	if v.Proxy != "" {
+		dnsDetail = fmt.Sprintf("proxy %s", dnsDetail)
+	}
+	p.pass(StageDns, dnsDetail)
+
+	// TCP
+	host := v.Host
+	if host == "" {
+		host = p.connectIP
+	}
+	target := fmt.Sprintf("%s port %d", host, v.Port)
+	if v.Proxy != "" {
+		target = fmt.Sprintf("the proxy %s", v.Proxy)
+	}
+	if !p.connected {
+		lower := strings.ToLower(p.connectError + " " + v.CurlError)
+		switch {
+		case strings.Contains(lower, "refused"):
+			p.fail(StageTcp, fmt.Sprintf("Connection to %s refused, nothing listens there or a firewall rejects it", target))
+		case strings.Contains(lower, "no route"):
+			p.fail(StageTcp, fmt.Sprintf("No route to %s", target))
+		case code == 28 || strings.Contains(lower, "timed out"):
+			p.fail(StageTcp, fmt.Sprintf("Connection to %s timed out, probably dropped by a firewall or NetworkPolicy", target))
+		default:
+			p.fail(StageTcp, fmt.Sprintf("Connection to %s failed: %s", target, orUnknown(v.CurlError)))
+		}
+		return
+	}
+	p.pass(StageTcp, fmt.Sprintf("connected to %s", p.connectIP))
+
+	// proxy CONNECT
+	if v.ProxyStatus != 0 || (v.Proxy != "" && strings.Contains(v.CurlError, "CONNECT")) {
+		if !p.tunnel {
+			p.fail(StageProxy, proxyCause(v.ProxyStatus, v.Host))
+			return
+		}
+		p.pass(StageProxy, fmt.Sprintf("CONNECT to %s:%d returned %d", v.Host, v.Port, v.ProxyStatus))
+	}
+
+	// TLS
+	if p.tlsStarted {
+		if cause := p.tlsCause(); cause != "" {
+			p.fail(StageTls, cause)
+			return
+		}
+		if !p.tlsDone {
+			p.fail(StageTls, fmt.Sprintf("TLS handshake failed: %s", orUnknown(v.CurlError)))
+			return
+		}
+		p.pass(StageTls, p.tlsDetail())
+	}
+
+	// HTTP
+	if v.HttpStatus == 0 {
+		switch {
+		case code == 28:
+			p.fail(StageHttp, "No response before the timeout")
+		case code == 52:
+			p.fail(StageHttp, "The server closed the connection without a response")
+		case code != 0:
+			p.fail(StageHttp, fmt.Sprintf("Request failed: %s", orUnknown(v.CurlError)))
+		default:
+			p.fail(StageHttp, "No HTTP response")
+		}
+		return
+	}
+	if v.Websocket && v.HttpStatus == http.StatusSwitchingProtocols {
+		p.pass(StageHttp, fmt.Sprintf("status %d", v.HttpStatus))
+		p.pass(StageWebsocket, "upgraded (101 Switching Protocols)")
+		v.Ok = true
+		return
+	}
+	if cause := httpCause(v.HttpStatus); cause != "" {
+		p.fail(StageHttp, cause)
+		return
+	}
+	p.pass(StageHttp, fmt.Sprintf("status %d", v.HttpStatus))
+	if v.Websocket {
+		p.fail(StageWebsocket, fmt.Sprintf("Websocket upgrade not accepted, got status %d instead of 101. A proxy or load balancer in between may not support websockets", v.HttpStatus))
+		return
+	}
+	v.Ok = code == 0
+	if !v.Ok {
+		p.fail(StageHttp, fmt.Sprintf("Request failed: %s", orUnknown(v.CurlError)))
+	}
+}
+
+func (p *parser) pass(stage Stage, detail string) {
+	p.verdict.Stages = append(p.verdict.Stages, StageResult{Stage: stage, Ok: true, Detail: detail})
+}
+
+func (p *parser) fail(stage Stage, cause string) {
+	p.verdict.Stages = append(p.verdict.Stages, StageResult{Stage: stage, Ok: false, Detail: cause})
+	p.verdict.FailedStage = stage
+	p.verdict.Cause = cause
+}
+
+func (p *parser) tlsCause() string {
+	v := &p.verdict
+	problem := p.tlsProblem
+	if problem == "" && v.CurlExitCode == 60 {
+		problem = strings.TrimPrefix(v.CurlError, "SSL certificate problem: ")
+	}
+	switch {
+	case v.CurlExitCode == 51:
+		return fmt.Sprintf("The certificate doesn't match the host name %s: %s", v.Host, v.CurlError)
+	case problem != "" || (p.verifyOk != nil && !*p.verifyOk && v.CurlExitCode != 0):
+		if problem == "" {
+			problem = v.Tls.VerifyResult
+		}
+		issuer := ""
+		if v.Tls != nil {
+			issuer = v.Tls.Issuer
+		}
+		if issuer != "" && !isPublicIssuer(issuer) {
+			return fmt.Sprintf("TLS intercepted by corporate proxy, issuer %s (%s)", issuer, problem)
+		}
+		if issuer == "" && isChainProblem(problem) {
+			return fmt.Sprintf("The certificate is not trusted (%s), the connection is probably intercepted by a TLS-inspecting proxy whose CA is missing", problem)
+		}
+		return fmt.Sprintf("The certificate is not trusted: %s", problem)
+	case v.CurlExitCode == 35:
+		lower := strings.ToLower(v.CurlError)
+		switch {
+		case strings.Contains(lower, "wrong version number") || strings.Contains(lower, "packet length too long"):
+			return "TLS handshake failed, the server doesn't speak TLS on this port"
+		case strings.Contains(lower, "reset") || strings.Contains(lower, "eof") || strings.Contains(lower, "syscall"):
+			return "The connection was closed during the TLS handshake, probably by a firewall or TLS-inspecting proxy"
+		}
+		return fmt.Sprintf("TLS handshake failed: %s", orUnknown(v.CurlError))
+	case v.CurlExitCode == 28 && !p.tlsDone:
+		return "TLS handshake timed out"
+	}
+	return ""
+}
+
+func (p *parser) tlsDetail() string {
+	t := p.verdict.Tls
+	if t == nil {
+		return "handshake completed"
+	}
+	parts := []string{strings.TrimSpace(t.Protocol + " " + t.Cipher)}
+	if t.Issuer != "" {
+		parts = append(parts, "issuer "+t.Issuer)
+	}
+	if t.VerifyResult != "" {
+		parts = append(parts, "verify "+t.VerifyResult)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func proxyCause(status int, host string) string {
+	switch {
+	case status == http.StatusProxyAuthRequired:
+		return "Proxy returned 407, it requires authentication. Configure the proxy credentials of the agent"
+	case status == http.StatusForbidden:
+		return fmt.Sprintf("Proxy returned 403, it doesn't allow connections to %s", host)
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return fmt.Sprintf("Proxy returned %d, it can't reach %s", status, host)
+	case status == 0:
+		return "The proxy closed the connection before answering the CONNECT request"
+	}
+	return fmt.Sprintf("Proxy returned %d to the CONNECT request", status)
+}
+
+// httpCause returns why a status denotes a failed connection. Other 4xx responses prove that the server was reached.
+func httpCause(status int) string {
+	switch {
+	case status == http.StatusForbidden:
+		return "Status 403, the request was probably blocked by a proxy, firewall or WAF"
+	case status == http.StatusProxyAuthRequired:
+		return "Proxy returned 407, it requires authentication. Configure the proxy credentials of the agent"
+	case status == http.StatusBadGateway:
+		return "Status 502, the ingress or a proxy can't reach the upstream service"
+	case status == http.StatusServiceUnavailable:
+		return "Status 503, the service or its ingress is not ready"
+	case status == http.StatusGatewayTimeout:
+		return "Status 504, the ingress or a proxy timed out waiting for the upstream service"
+	case status >= 500:
+		return fmt.Sprintf("Status %d, the server failed", status)
+	}
+	return ""
+}
+
+func isPublicIssuer(issuer string) bool {
+	for _, public := range publicIssuers {
+		if strings.Contains(issuer, public) {
+			return true
+		}
+	}
+	return false
+}
+
+func isChainProblem(problem string) bool {
+	problem = strings.ToLower(problem)
+	return strings.Contains(problem, "self-signed certificate in certificate chain") ||
+		strings.Contains(problem, "self signed certificate in certificate chain") ||
+		strings.Contains(problem, "unable to get local issuer certificate") ||
+		strings.Contains(problem, "unable to verify the first certificate")
+}
+
+func hostOf(curlError string) string {
+	if m := urlHostPattern.FindStringSubmatch(curlError); m != nil {
+		return m[1]
+	}
+	return "the host"
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown error"
+	}
+	return value
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package connectivity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCurl(t *testing.T) {
+	tests := []struct {
+		file        string
+		ok          bool
+		failedStage Stage
+		cause       string
+		exitCode    int
+		host        string
+		port        int
+		proxyStatus int
+		httpStatus  int
+		tlsProtocol string
+		tlsIssuer   string
+	}{
+		{file: "curl7_ok.txt", ok: true, host: "platform.steadybit.com", port: 443, httpStatus: 200,
+			tlsProtocol: "TLSv1.3", tlsIssuer: "C=US; O=Let's Encrypt; CN=R11"},
+		{file: "curl8_ok.txt", ok: true, host: "platform.steadybit.com", port: 443, httpStatus: 200,
+			tlsProtocol: "TLSv1.3", tlsIssuer: "C=US; O=Let's Encrypt; CN=R11"},
+		{file: "curl8_proxy_ok.txt", ok: true, host: "platform.steadybit.com", port: 443, proxyStatus: 200, httpStatus: 200,
+			tlsProtocol: "TLSv1.3", tlsIssuer: "C=US; O=Let's Encrypt; CN=R11"},
+		{file: "curl8_proxy_407.txt", failedStage: StageProxy, exitCode: 56, host: "platform.steadybit.com", port: 443, proxyStatus: 407,
+			cause: "Proxy returned 407, it requires authentication. Configure the proxy credentials of the agent"},
+		{file: "curl7_proxy_403.txt", failedStage: StageProxy, exitCode: 56, host: "platform.steadybit.com", port: 443, proxyStatus: 403,
+			cause: "Proxy returned 403, it doesn't allow connections to platform.steadybit.com"},
+		{file: "curl8_dns.txt", failedStage: StageDns, exitCode: 6,
+			cause: "DNS lookup of platform.steadybit.com failed, check the host name and the cluster DNS"},
+		{file: "curl7_proxy_dns.txt", failedStage: StageDns, exitCode: 5,
+			cause: "DNS lookup of the proxy proxy.corp.example failed, check the proxy host name"},
+		{file: "curl8_refused.txt", failedStage: StageTcp, exitCode: 7, host: "platform.steadybit.com", port: 443,
+			cause: "Connection to platform.steadybit.com port 443 refused, nothing listens there or a firewall rejects it"},
+		{file: "curl7_timeout.txt", failedStage: StageTcp, exitCode: 28, port: 443,
+			cause: "Connection to 10.1.2.3 port 443 timed out, probably dropped by a firewall or NetworkPolicy"},
+		{file: "curl8_wrong_version.txt", failedStage: StageTls, exitCode: 35, host: "platform.steadybit.com", port: 443,
+			cause: "TLS handshake failed, the server doesn't speak TLS on this port"},
+		{file: "curl7_reset.txt", failedStage: StageTls, exitCode: 35, host: "platform.steadybit.com", port: 443,
+			cause: "The connection was closed during the TLS handshake, probably by a firewall or TLS-inspecting proxy"},
+		{file: "curl7_hostname_mismatch.txt", failedStage: StageTls, exitCode: 51, host: "platform.steadybit.com", port: 443, tlsProtocol: "TLSv1.3",
+			cause: "The certificate doesn't match the host name platform.steadybit.com: SSL: no alternative certificate subject name matches target host name 'platform.steadybit.com'"},
+		{file: "curl8_untrusted_chain.txt", failedStage: StageTls, exitCode: 60, host: "platform.steadybit.com", port: 443,
+			cause: "The certificate is not trusted (self-signed certificate in certificate chain), the connection is probably intercepted by a TLS-inspecting proxy whose CA is missing"},
+		{file: "curl7_intercepted.txt", failedStage: StageTls, exitCode: 60, host: "platform.steadybit.com", port: 443,
+			tlsProtocol: "TLSv1.2", tlsIssuer: "C=DE; O=Example Corp; CN=Example Corp SSL Inspection CA",
+			cause: "TLS intercepted by corporate proxy, issuer C=DE; O=Example Corp; CN=Example Corp SSL Inspection CA (unable to get local issuer certificate)"},
+		{file: "curl8_websocket.txt", ok: true, host: "platform.steadybit.com", port: 443, httpStatus: 101,
+			tlsProtocol: "TLSv1.3", tlsIssuer: "C=US; O=Let's Encrypt; CN=R11"},
+		{file: "curl8_websocket_rejected.txt", failedStage: StageWebsocket, host: "platform.steadybit.com", port: 443, httpStatus: 200,
+			tlsProtocol: "TLSv1.3", tlsIssuer: "C=US; O=Let's Encrypt; CN=R11",
+			cause: "Websocket upgrade not accepted, got status 200 instead of 101. A proxy or load balancer in between may not support websockets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := ParseCurl(string(content))
+			if v.Ok != tt.ok || v.FailedStage != tt.failedStage || v.Cause != tt.cause {
+				t.Errorf("got ok %v, failed stage %q, cause %q\nwant ok %v, failed stage %q, cause %q", v.Ok, v.FailedStage, v.Cause, tt.ok, tt.failedStage, tt.cause)
+			}
+			if v.CurlExitCode != tt.exitCode {
+				t.Errorf("got exit code %d, want %d", v.CurlExitCode, tt.exitCode)
+			}
+			if v.Host != tt.host || v.Port != tt.port {
+				t.Errorf("got %s:%d, want %s:%d", v.Host, v.Port, tt.host, tt.port)
+			}
+			if v.ProxyStatus != tt.proxyStatus || v.HttpStatus != tt.httpStatus {
+				t.Errorf("got proxy status %d and http status %d, want %d and %d", v.ProxyStatus, v.HttpStatus, tt.proxyStatus, tt.httpStatus)
+			}
+			var protocol, issuer string
+			if v.Tls != nil {
+				protocol, issuer = v.Tls.Protocol, v.Tls.Issuer
+			}
+			if protocol != tt.tlsProtocol || issuer != tt.tlsIssuer {
+				t.Errorf("got tls %q issued by %q, want %q issued by %q", protocol, issuer, tt.tlsProtocol, tt.tlsIssuer)
+			}
+			if !v.Ok && (len(v.Stages) == 0 || v.Stages[len(v.Stages)-1].Stage != tt.failedStage) {
+				t.Errorf("the failed stage is not the last one: %v", v.Stages)
+			}
+		})
+	}
+}
+
+func TestParseCurlStages(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "curl8_proxy_ok.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := ParseCurl(string(content))
+	want := []Stage{StageDns, StageTcp, StageProxy, StageTls, StageHttp}
+	if len(v.Stages) != len(want) {
+		t.Fatalf("got stages %v, want %v", v.Stages, want)
+	}
+	for i, stage := range want {
+		if v.Stages[i].Stage != stage || !v.Stages[i].Ok {
+			t.Errorf("got stage %d %v, want %s ok", i, v.Stages[i], stage)
+		}
+	}
+	if v.Proxy != "http://proxy.corp.example:3128" {
+		t.Errorf("got proxy %q", v.Proxy)
+	}
+	if got := v.String(); got != "ok (TLSv1.3, via proxy, status 200)" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseCurlHttpStatus(t *testing.T) {
+	response := func(status string) string {
+		return "*   Trying 10.1.2.3:8080...\n" +
+			"* Connected to steadybit-platform (10.1.2.3) port 8080 (#0)\n" +
+			"> GET /api/health HTTP/1.1\n" +
+			"> Host: steadybit-platform:8080\n" +
+			">\n" +
+			"< HTTP/1.1 " + status + "\n" +
+			"<\n"
+	}
+	tests := []struct {
+		name   string
+		output string
+		ok     bool
+		cause  string
+	}{
+		{name: "ok", output: response("200 OK"), ok: true},
+		{name: "not found proves the server was reached", output: response("404 Not Found"), ok: true},
+		{name: "blocked", output: response("403 Forbidden"), cause: "Status 403, the request was probably blocked by a proxy, firewall or WAF"},
+		{name: "bad gateway", output: response("502 Bad Gateway"), cause: "Status 502, the ingress or a proxy can't reach the upstream service"},
+		{name: "unavailable", output: response("503 Service Unavailable"), cause: "Status 503, the service or its ingress is not ready"},
+		{name: "redirect replaced by later response", output: response("301 Moved Permanently") + "> GET /health HTTP/1.1\n< HTTP/1.1 200 OK\n", ok: true},
+		{name: "empty reply", output: "*   Trying 10.1.2.3:8080...\n* Connected to steadybit-platform (10.1.2.3) port 8080 (#0)\n> GET /api/health HTTP/1.1\n>\n* Empty reply from server\ncurl: (52) Empty reply from server\n",
+			cause: "The server closed the connection without a response"},
+		{name: "no curl output", output: "error: unable to upgrade connection: container not found (\"curl\")\n",
+			cause: "The connection test produced no curl output, it probably didn't run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := ParseCurl(tt.output)
+			if v.Ok != tt.ok || v.Cause != tt.cause {
+				t.Errorf("got ok %v, cause %q, want ok %v, cause %q", v.Ok, v.Cause, tt.ok, tt.cause)
+			}
+		})
+	}
+}
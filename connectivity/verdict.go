@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package connectivity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Result is the verdict of a connection test stored at Path
+type Result struct {
+	Path    string
+	Verdict Verdict
+}
+
+var results = struct {
+	mu      sync.Mutex
+	results []Result
+}{}
+
+// Record remembers the verdict of a connection test for the run summary
+func Record(path string, verdict Verdict) {
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.results = append(results.results, Result{Path: path, Verdict: verdict})
+}
+
+// Results returns the recorded verdicts sorted by path
+func Results() []Result {
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	sorted := append([]Result(nil), results.results...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	return sorted
+}
+
+// VerdictPath returns the path the verdict of the connection test stored at testPath is written to
+func VerdictPath(testPath string) string {
+	return strings.TrimSuffix(testPath, ".txt") + ".verdict.json"
+}
+
+// String returns a one-line verdict, e.g. "ok (TLSv1.3, status 200)" or "tls failed: The certificate is not trusted"
+func (v Verdict) String() string {
+	if v.Ok {
+		details := []string{}
+		if v.Tls != nil && v.Tls.Protocol != "" {
+			details = append(details, v.Tls.Protocol)
+		}
+		if v.ProxyStatus != 0 {
+			details = append(details, "via proxy")
+		}
+		details = append(details, fmt.Sprintf("status %d", v.HttpStatus))
+		return fmt.Sprintf("ok (%s)", strings.Join(details, ", "))
+	}
+	if v.FailedStage == "" {
+		return fmt.Sprintf("unknown: %s", v.Cause)
+	}
+	return fmt.Sprintf("%s failed: %s", v.FailedStage, v.Cause)
+}
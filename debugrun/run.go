@@ -5,42 +5,219 @@
 package debugrun
 
 import (
-	"github.com/steadybit/steadybit-debug/agent"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	_ "github.com/steadybit/steadybit-debug/agent"
+	"github.com/steadybit/steadybit-debug/collector"
 	"github.com/steadybit/steadybit-debug/config"
-	"github.com/steadybit/steadybit-debug/extensions"
+	_ "github.com/steadybit/steadybit-debug/extensions"
 	"github.com/steadybit/steadybit-debug/k8s"
-	"github.com/steadybit/steadybit-debug/platform"
+	"github.com/steadybit/steadybit-debug/output"
+	_ "github.com/steadybit/steadybit-debug/platform"
+	"io"
+	"path/filepath"
 	"sync"
+	"time"
 )
 
-func GatherInformation(cfg *config.Config) {
-	var wg sync.WaitGroup
-	wg.Add(5)
+// GatherInformation runs the collectors until they are done or ctx is cancelled. Artifacts and collectors that were cut
+// short are listed in incomplete.json, so that a partial bundle can still be archived.
+func GatherInformation(ctx context.Context, cfg *config.Config, collectors []collector.Collector) {
+	defer k8s.ClosePortForwards()
+	defer k8s.DeleteProbePods(cfg)
+
+	results := runCollectors(ctx, cfg, collectors)
+
+	addKubernetesApiStats(cfg)
+	addIncompleteReport(ctx, cfg, results)
+	addSummary(cfg, results)
+	addVersionsReport(cfg)
+	addThreadDumpDigests(cfg)
+	output.AddRedactionReport(cfg)
+}
 
-	go func() {
-		defer wg.Done()
-		platform.AddPlatformDebuggingInformation(cfg)
-	}()
+type collectorResult struct {
+	name string
+	err  error
+	// incomplete is the reason why the collector didn't finish
+	incomplete string
+}
+
+// runCollectors runs all collectors concurrently. A collector waits for its dependencies, unless they are not selected for this run.
+// The results are in the same order as the collectors.
+func runCollectors(ctx context.Context, cfg *config.Config, collectors []collector.Collector) []collectorResult {
+	done := make(map[string]chan struct{}, len(collectors))
+	for _, c := range collectors {
+		done[c.Name()] = make(chan struct{})
+	}
 
-	go func() {
-		defer wg.Done()
-		platform.AddPlatformPortSplitterDebuggingInformation(cfg)
-	}()
+	results := make([]collectorResult, len(collectors))
+	var wg sync.WaitGroup
+	for i, c := range collectors {
+		wg.Add(1)
+		go func(result *collectorResult, c collector.Collector) {
+			defer wg.Done()
+			defer close(done[c.Name()])
 
-	go func() {
-		defer wg.Done()
-		agent.AddAgentDebuggingInformation(cfg)
-	}()
+			result.name = c.Name()
+			markIncomplete := func(reason string) {
+				log.Warn().Msgf("Collector '%s' is incomplete: %s", c.Name(), reason)
+				result.incomplete = reason
+			}
 
-	go func() {
-		defer wg.Done()
-		k8s.AddKubernetesNodesInformation(cfg)
-	}()
+			for _, dependency := range c.Dependencies() {
+				if dependencyDone, ok := done[dependency]; ok {
+					select {
+					case <-dependencyDone:
+					case <-ctx.Done():
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				markIncomplete("not started, " + output.CancellationReason(ctx))
+				return
+			}
 
-	go func() {
-		defer wg.Done()
-		extensions.AddExtensionDebuggingInformation(cfg)
-	}()
+			collectorCtx := output.WithCollector(ctx, c.Name())
+			if timeout := cfg.CollectorTimeouts[c.Name()].Duration(); timeout > 0 {
+				var cancel context.CancelFunc
+				collectorCtx, cancel = context.WithTimeoutCause(collectorCtx, timeout, fmt.Errorf("collector timeout of %s exceeded", timeout))
+				defer cancel()
+			}
 
+			log.Debug().Msgf("Running collector '%s'", c.Name())
+			start := time.Now()
+			result.err = c.Collect(collectorCtx, &sink{cfg: cfg})
+			if result.err != nil {
+				log.Warn().Err(result.err).Msgf("Collector '%s' failed", c.Name())
+			}
+			if reason := output.CancellationReason(collectorCtx); reason != "" {
+				markIncomplete(reason)
+			}
+			log.Debug().Msgf("Collector '%s' finished after %d millis", c.Name(), time.Since(start).Milliseconds())
+		}(&results[i], c)
+	}
 	wg.Wait()
+	return results
+}
+
+type sink struct {
+	cfg *config.Config
+}
+
+func (s *sink) Config() *config.Config {
+	return s.cfg
+}
+
+func (s *sink) OutputPath(elem ...string) string {
+	return filepath.Join(append([]string{s.cfg.OutputPath}, elem...)...)
+}
+
+func (s *sink) Write(path string, content []byte) {
+	output.WriteToFile(s.OutputPath(path), content)
+}
+
+func addKubernetesApiStats(cfg *config.Config) {
+	stats := config.KubernetesApiStats()
+	log.Info().Msgf("Kubernetes API: %d requests (%d failed, %d throttled by the server), waited %s due to client-side throttling",
+		stats.TotalRequests, stats.FailedRequests, stats.ServerSideThrottled, stats.ClientSideThrottling.TotalWait)
+	output.AddJsonOutput(output.AddJsonOutputOptions{
+		Config:     cfg,
+		Content:    stats,
+		OutputPath: []string{"kubernetes_api_stats.json"},
+	})
+}
+
+type incompleteCollector struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+type incompleteReport struct {
+	Reason     string                      `json:"reason,omitempty"`
+	Collectors []incompleteCollector       `json:"collectors"`
+	Artifacts  []output.IncompleteArtifact `json:"artifacts"`
+}
+
+// addIncompleteReport writes incomplete.json when the run or single collectors got cancelled. Artifact paths are relative to the bundle.
+func addIncompleteReport(ctx context.Context, cfg *config.Config, results []collectorResult) {
+	collectors := []incompleteCollector{}
+	for _, result := range results {
+		if result.incomplete != "" {
+			collectors = append(collectors, incompleteCollector{Name: result.name, Reason: result.incomplete})
+		}
+	}
+	artifacts := output.IncompleteArtifacts()
+	if len(collectors) == 0 && len(artifacts) == 0 {
+		return
+	}
+	for i, artifact := range artifacts {
+		if path, err := filepath.Rel(cfg.OutputPath, artifact.Path); err == nil {
+			artifacts[i].Path = path
+		}
+	}
+
+	report := incompleteReport{
+		Reason:     output.CancellationReason(ctx),
+		Collectors: collectors,
+		Artifacts:  artifacts,
+	}
+	if report.Reason != "" {
+		log.Warn().Msgf("Run got cancelled (%s), the bundle is incomplete. See incomplete.json for details.", report.Reason)
+	} else {
+		log.Warn().Msgf("%d collector(s) timed out, the bundle is incomplete. See incomplete.json for details.", len(collectors))
+	}
+	output.AddJsonOutput(output.AddJsonOutputOptions{
+		Config:     cfg,
+		Content:    report,
+		OutputPath: []string{"incomplete.json"},
+	})
+}
+
+type plannedCollector struct {
+	Name  string               `json:"name"`
+	Error string               `json:"error,omitempty"`
+	Steps []output.PlannedStep `json:"steps"`
+}
+
+// Plan discovers the workloads with read-only API requests and writes what the collectors would do to w, without
+// executing any commands, port-forwards or requests
+func Plan(ctx context.Context, cfg *config.Config, collectors []collector.Collector, w io.Writer) error {
+	output.EnableDryRun()
+	defer k8s.ClosePortForwards()
+
+	results := runCollectors(ctx, cfg, collectors)
+
+	names := make([]string, 0, len(results))
+	notes := map[string]string{}
+	for _, result := range results {
+		names = append(names, result.name)
+		if result.err != nil {
+			notes[result.name] = result.err.Error()
+		} else if result.incomplete != "" {
+			notes[result.name] = "incomplete, " + result.incomplete
+		}
+	}
+
+	if cfg.PlanFormat == "json" {
+		planned := make([]plannedCollector, 0, len(results))
+		steps := output.PlannedSteps()
+		for _, name := range names {
+			c := plannedCollector{Name: name, Error: notes[name], Steps: []output.PlannedStep{}}
+			for _, step := range steps {
+				if step.Collector == name {
+					c.Steps = append(c.Steps, step)
+				}
+			}
+			planned = append(planned, c)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(map[string]any{"collectors": planned})
+	}
+
+	output.WritePlanTree(w, names, notes)
+	return nil
 }
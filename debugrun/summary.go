@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package debugrun
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/connectivity"
+	"github.com/steadybit/steadybit-debug/output"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// maxErrorReasons limits the number of error reasons shown per collector
+const maxErrorReasons = 3
+
+type collectorSummary struct {
+	name       string
+	succeeded  int
+	failed     int
+	skipped    int
+	incomplete int
+	reasons    map[string]int
+	err        error
+	cancelled  string
+}
+
+func (s *collectorSummary) status() string {
+	switch {
+	case s.err != nil:
+		return "failed"
+	case s.cancelled != "":
+		return "incomplete"
+	case s.failed > 0 && s.succeeded == 0:
+		return "failed"
+	case s.failed > 0:
+		return "degraded"
+	case s.succeeded+s.skipped+s.incomplete == 0:
+		return "empty"
+	default:
+		return "ok"
+	}
+}
+
+// mainReasons returns the most frequent error reasons, e.g. "port-forward failed (3), 404 (1)"
+func (s *collectorSummary) mainReasons() string {
+	reasons := make([]string, 0, len(s.reasons))
+	for reason := range s.reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if s.reasons[reasons[i]] != s.reasons[reasons[j]] {
+			return s.reasons[reasons[i]] > s.reasons[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	if len(reasons) > maxErrorReasons {
+		reasons = reasons[:maxErrorReasons]
+	}
+	if len(reasons) == 0 {
+		return "-"
+	}
+	for i, reason := range reasons {
+		reasons[i] = fmt.Sprintf("%s (%d)", reason, s.reasons[reason])
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// addSummary prints the number of succeeded, failed and skipped artifacts per collector and stores it as summary.txt
+func addSummary(cfg *config.Config, results []collectorResult) {
+	summaries := make(map[string]*collectorSummary, len(results))
+	for _, result := range results {
+		summary := &collectorSummary{name: result.name, reasons: map[string]int{}, err: result.err, cancelled: result.incomplete}
+		if result.err != nil {
+			summary.reasons[errorReason(result.err.Error())]++
+		}
+		summaries[result.name] = summary
+	}
+
+	for _, artifact := range output.Artifacts() {
+		summary, ok := summaries[artifact.Collector]
+		if !ok {
+			continue
+		}
+		switch artifact.Status {
+		case output.ArtifactSucceeded:
+			summary.succeeded++
+		case output.ArtifactFailed:
+			summary.failed++
+			summary.reasons[errorReason(artifact.Error)]++
+		case output.ArtifactSkipped:
+			summary.skipped++
+		case output.ArtifactIncomplete:
+			summary.incomplete++
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "Collection summary:")
+	tw := tabwriter.NewWriter(&buf, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTOR\tSTATUS\tSUCCEEDED\tFAILED\tSKIPPED\tINCOMPLETE\tMAIN ERRORS")
+	for _, result := range results {
+		summary := summaries[result.name]
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%s\n", summary.name, summary.status(), summary.succeeded, summary.failed, summary.skipped, summary.incomplete, summary.mainReasons())
+	}
+	tw.Flush()
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(&buf, "\n%s: %s", result.name, result.err)
+		} else if result.incomplete != "" {
+			fmt.Fprintf(&buf, "\n%s: incomplete, %s", result.name, result.incomplete)
+		}
+	}
+	fmt.Fprintln(&buf)
+	writeConnectionVerdicts(&buf, cfg)
+
+	fmt.Fprint(os.Stderr, "\n"+buf.String()+"\n")
+	output.WriteToFile(filepath.Join(cfg.OutputPath, "summary.txt"), buf.Bytes())
+}
+
+// writeConnectionVerdicts lists the verdicts of the curl connection tests, see connectivity.ParseCurl
+func writeConnectionVerdicts(buf *bytes.Buffer, cfg *config.Config) {
+	verdicts := connectivity.Results()
+	if len(verdicts) == 0 {
+		return
+	}
+	fmt.Fprintln(buf, "\nConnection tests:")
+	tw := tabwriter.NewWriter(buf, 0, 4, 3, ' ', 0)
+	for _, result := range verdicts {
+		path, err := filepath.Rel(cfg.OutputPath, result.Path)
+		if err != nil {
+			path = result.Path
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", path, result.Verdict)
+	}
+	tw.Flush()
+}
+
+var (
+	statusCodePattern = regexp.MustCompile(`status code (\d{3})`)
+	exitStatusPattern = regexp.MustCompile(`exit status (\d+)`)
+)
+
+// errorReason condenses an error message into a short reason to group failures by
+func errorReason(message string) string {
+	// client-go adds the request timeout to each URL, which must not be mistaken for a timeout error
+	lower := strings.ReplaceAll(strings.ToLower(message), "?timeout=", "?")
+	switch {
+	case strings.HasPrefix(lower, "disabled by preflight"):
+		return "disabled by preflight"
+	case strings.Contains(lower, "ephemeral") && strings.Contains(lower, "forbidden"):
+		return "ephemeral containers forbidden"
+	case strings.Contains(lower, "port-forward"):
+		return "port-forward failed"
+	case strings.Contains(lower, "bad certificate"):
+		return "TLS bad certificate"
+	case strings.Contains(lower, "x509:") || strings.Contains(lower, "tls:"):
+		return "TLS error"
+	case statusCodePattern.MatchString(lower):
+		return statusCodePattern.FindStringSubmatch(lower)[1]
+	case strings.Contains(lower, "forbidden"):
+		return "forbidden"
+	case strings.Contains(lower, "unauthorized"):
+		return "unauthorized"
+	case strings.Contains(lower, "not found"):
+		return "not found"
+	case strings.Contains(lower, "connection refused"):
+		return "connection refused"
+	case strings.Contains(lower, "deadline exceeded") || strings.Contains(lower, "timeout") || strings.Contains(lower, "timed out"):
+		return "timeout"
+	case strings.Contains(lower, "context canceled"):
+		return "cancelled"
+	case exitStatusPattern.MatchString(lower):
+		return exitStatusPattern.FindString(lower)
+	}
+	if len(message) > 60 {
+		return message[:57] + "..."
+	}
+	return message
+}
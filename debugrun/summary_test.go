@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package debugrun
+
+import (
+	"testing"
+)
+
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		message string
+		reason  string
+	}{
+		{message: "error upgrading connection: port-forward to pod steadybit-agent-0 failed: tls: bad certificate", reason: "port-forward failed"},
+		{message: "Get \"https://10.0.0.1:8443/health\": remote error: tls: bad certificate", reason: "TLS bad certificate"},
+		{message: "Get \"https://10.0.0.1:8443/health\": x509: certificate signed by unknown authority", reason: "TLS error"},
+		{message: "pods \"steadybit-agent-0\" is forbidden: User \"dev\" cannot patch resource \"pods/ephemeralcontainers\"", reason: "ephemeral containers forbidden"},
+		{message: "request failed with status code 404", reason: "404"},
+		{message: "Get \"https://10.0.0.1:6443/api/v1/namespaces/steadybit-agent/pods?timeout=32s\": status code 503", reason: "503"},
+		{message: "Get \"https://10.0.0.1:6443/api/v1/nodes?timeout=32s\": dial tcp 10.0.0.1:6443: connect: connection refused", reason: "connection refused"},
+		{message: "Get \"https://10.0.0.1:6443/api/v1/nodes?timeout=32s\": context deadline exceeded", reason: "timeout"},
+		{message: "Get \"https://10.0.0.1:6443/api/v1/nodes?timeout=32s\": EOF", reason: "Get \"https://10.0.0.1:6443/api/v1/nodes?timeout=32s\": EOF"},
+		{message: "nodes is forbidden: User \"dev\" cannot list resource \"nodes\"", reason: "forbidden"},
+		{message: "disabled by preflight: missing permission list nodes", reason: "disabled by preflight"},
+		{message: "context canceled", reason: "cancelled"},
+		{message: "command terminated: exit status 127", reason: "exit status 127"},
+		{message: "something unexpected", reason: "something unexpected"},
+		{message: "a very long error message that doesn't match any of the known reasons at all", reason: "a very long error message that doesn't match any of the k..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			if reason := errorReason(tt.message); reason != tt.reason {
+				t.Errorf("got reason '%s' for '%s', want '%s'", reason, tt.message, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package debugrun
+
+import (
+	"bytes"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/output"
+	"github.com/steadybit/steadybit-debug/threaddump"
+	"path/filepath"
+)
+
+func init() {
+	output.RetainFiles(threaddump.SourceFiles...)
+}
+
+// addThreadDumpDigests stores a digest next to the thread dumps of each platform and agent pod and logs deadlocks and
+// stuck threads
+func addThreadDumpDigests(cfg *config.Config) {
+	files, err := output.CollectedFiles(cfg)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to read the collected files for the thread dump digests")
+		return
+	}
+	for _, digest := range threaddump.Digests(bundle.New(filepath.Base(cfg.OutputPath), files)) {
+		var buf bytes.Buffer
+		digest.Write(&buf)
+		output.WriteToFile(filepath.Join(cfg.OutputPath, filepath.FromSlash(digest.Dir), threaddump.DigestFile), buf.Bytes())
+
+		if len(digest.Deadlocks) > 0 {
+			log.Error().Msgf("Found %d deadlocks in the thread dump of %s, see %s", len(digest.Deadlocks), digest.Dir, threaddump.DigestFile)
+		}
+		if len(digest.Stuck) > 0 {
+			log.Warn().Msgf("Found %d stuck threads in the thread dumps of %s, see %s", len(digest.Stuck), digest.Dir, threaddump.DigestFile)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package debugrun
+
+import (
+	"bytes"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/output"
+	"github.com/steadybit/steadybit-debug/versions"
+	"path/filepath"
+)
+
+func init() {
+	output.RetainFiles(versions.SourceFiles...)
+}
+
+// addVersionsReport stores the versions of all components as versions.json and versions.txt and logs the
+// incompatibilities found
+func addVersionsReport(cfg *config.Config) {
+	files, err := output.CollectedFiles(cfg)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to read the collected files for the versions report")
+		return
+	}
+	report := versions.NewReport(bundle.New(filepath.Base(cfg.OutputPath), files))
+	output.AddJsonOutput(output.AddJsonOutputOptions{
+		Config:     cfg,
+		Content:    report,
+		OutputPath: []string{"versions.json"},
+	})
+	var buf bytes.Buffer
+	report.Write(&buf)
+	output.WriteToFile(filepath.Join(cfg.OutputPath, "versions.txt"), buf.Bytes())
+
+	for _, incompatibility := range report.Incompatibilities {
+		if incompatibility.Severity == "error" {
+			log.Error().Msgf("Incompatible versions: %s (rule %s)", incompatibility.Message, incompatibility.Rule)
+		} else {
+			log.Warn().Msgf("Incompatible versions: %s (rule %s)", incompatibility.Message, incompatibility.Rule)
+		}
+	}
+}
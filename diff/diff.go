@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+// Package diff compares the components of two bundles, e.g. to find out what changed since a setup last worked
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Modified ChangeType = "modified"
+)
+
+// Change is a fact that differs between the two bundles. Old is empty for additions, New for removals.
+type Change struct {
+	Section   string     `json:"section"`
+	Component string     `json:"component"`
+	Key       string     `json:"key"`
+	Type      ChangeType `json:"type"`
+	Old       string     `json:"old,omitempty"`
+	New       string     `json:"new,omitempty"`
+}
+
+type Report struct {
+	A       string   `json:"a"`
+	B       string   `json:"b"`
+	Changes []Change `json:"changes"`
+}
+
+// Compare reports the changes from a to b by section. Components that only exist in one of the bundles are only
+// reported in the components section.
+func Compare(a *Snapshot, b *Snapshot) Report {
+	report := Report{A: a.Name, B: b.Name, Changes: []Change{}}
+	components := append([]string{}, a.Components...)
+	for _, component := range b.Components {
+		if !a.hasComponent(component) {
+			components = append(components, component)
+		}
+	}
+	for _, section := range Sections {
+		for _, component := range components {
+			if section != SectionComponents && !(a.hasComponent(component) && b.hasComponent(component)) {
+				continue
+			}
+			report.Changes = append(report.Changes, compareValues(section, component, a.Values(section, component), b.Values(section, component))...)
+		}
+	}
+	return report
+}
+
+func compareValues(section string, component string, a map[string]string, b map[string]string) []Change {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []Change
+	for _, key := range keys {
+		oldValue, inA := a[key]
+		newValue, inB := b[key]
+		change := Change{Section: section, Component: component, Key: key, Old: oldValue, New: newValue}
+		switch {
+		case !inA:
+			change.Type = Added
+		case !inB:
+			change.Type = Removed
+		case oldValue != newValue:
+			change.Type = Modified
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Count returns the number of changes of the given type
+func (r Report) Count(changeType ChangeType) int {
+	count := 0
+	for _, change := range r.Changes {
+		if change.Type == changeType {
+			count++
+		}
+	}
+	return count
+}
+
+// Write prints the changes grouped by section, e.g.
+//
+//	Versions
+//	  ~ agent: build.version: 2.1.0 -> 2.2.0
+func (r Report) Write(w io.Writer) error {
+	fmt.Fprintf(w, "Comparing %s with %s: %d added, %d removed, %d modified\n", r.A, r.B, r.Count(Added), r.Count(Removed), r.Count(Modified))
+	section := ""
+	for _, change := range r.Changes {
+		if change.Section != section {
+			section = change.Section
+			fmt.Fprintf(w, "\n%s%s\n", strings.ToUpper(section[:1]), section[1:])
+		}
+		subject := fmt.Sprintf("%s: %s", change.Component, change.Key)
+		if change.Section == SectionComponents {
+			subject = change.Component
+		}
+		switch change.Type {
+		case Added:
+			fmt.Fprintf(w, "  + %s%s\n", subject, formatValue(change.New))
+		case Removed:
+			fmt.Fprintf(w, "  - %s%s\n", subject, formatValue(change.Old))
+		case Modified:
+			fmt.Fprintf(w, "  ~ %s: %s -> %s\n", subject, orNone(change.Old), orNone(change.New))
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func formatValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", value)
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
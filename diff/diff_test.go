@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package diff
+
+import (
+	"github.com/steadybit/steadybit-debug/bundle"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const (
+	agentPod   = "agent/pods/steadybit-agent-0/"
+	httpPod    = "extensions/steadybit-agent/extension-http/pods/extension-http-0/"
+	httpAction = "com.steadybit.extension_http.check"
+)
+
+func TestCompare(t *testing.T) {
+	a := newBundle("steadybit-debug-1", map[string]string{
+		"agent/config.yaml":                     workload("agent:2.1.0", "INFO", "        - name: STEADYBIT_AGENT_KEY\n          valueFrom:\n            secretKeyRef:\n              name: agent\n              key: key\n"),
+		agentPod + "info.yml":                   `{"build": {"version": "2.1.0"}}`,
+		agentPod + "description.txt":            description(0),
+		httpPod + "http/GET__.yml":              `{"actions": [{"path": "/actions/check"}]}`,
+		httpPod + "http/GET__actions_check.yml": `{"id": "` + httpAction + `", "version": "1.0.0"}`,
+		"extensions/steadybit-agent/extension-host/pods/extension-host-0/logs.txt": "",
+	})
+	b := newBundle("steadybit-debug-2", map[string]string{
+		"agent/config.yaml":                     workload("agent:2.2.0", "DEBUG", ""),
+		agentPod + "info.yml":                   `{"build": {"version": "2.2.0"}}`,
+		agentPod + "description.txt":            description(3),
+		httpPod + "http/GET__.yml":              `{"actions": [{"path": "/actions/check"}], "discoveries": [{"path": "/discoveries/http"}]}`,
+		httpPod + "http/GET__actions_check.yml": `{"id": "` + httpAction + `", "version": "1.1.0"}`,
+		"extensions/steadybit-agent/extension-jvm/pods/extension-jvm-0/logs.txt": "",
+	})
+
+	report := Compare(NewSnapshot(a), NewSnapshot(b))
+	want := []Change{
+		{Section: SectionComponents, Component: "extension steadybit-agent/extension-host", Key: "extension steadybit-agent/extension-host", Type: Removed, Old: "1 pod"},
+		{Section: SectionComponents, Component: "extension steadybit-agent/extension-jvm", Key: "extension steadybit-agent/extension-jvm", Type: Added, New: "1 pod"},
+		{Section: SectionVersions, Component: "agent", Key: "build.version", Type: Modified, Old: "2.1.0", New: "2.2.0"},
+		{Section: SectionImages, Component: "agent", Key: "steadybit-agent", Type: Modified, Old: "agent:2.1.0", New: "agent:2.2.0"},
+		{Section: SectionEnv, Component: "agent", Key: "steadybit-agent/STEADYBIT_AGENT_KEY", Type: Removed, Old: `<from {"secretKeyRef":{"key":"key","name":"agent"}}>`},
+		{Section: SectionEnv, Component: "agent", Key: "steadybit-agent/STEADYBIT_LOG_LEVEL", Type: Modified, Old: "INFO", New: "DEBUG"},
+		{Section: SectionRestarts, Component: "agent", Key: "steadybit-agent", Type: Modified, Old: "0", New: "3"},
+		{Section: SectionActions, Component: "extension steadybit-agent/extension-http", Key: "actions " + httpAction, Type: Modified, Old: "1.0.0", New: "1.1.0"},
+		{Section: SectionActions, Component: "extension steadybit-agent/extension-http", Key: "discoveries /discoveries/http", Type: Added},
+	}
+	if report.A != "steadybit-debug-1" || report.B != "steadybit-debug-2" {
+		t.Errorf("got bundles %s and %s", report.A, report.B)
+	}
+	if len(report.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(report.Changes), len(want), report.Changes)
+	}
+	for i, change := range report.Changes {
+		if !reflect.DeepEqual(change, want[i]) {
+			t.Errorf("got change %+v, want %+v", change, want[i])
+		}
+	}
+	if added, removed, modified := report.Count(Added), report.Count(Removed), report.Count(Modified); added != 2 || removed != 2 || modified != 5 {
+		t.Errorf("got %d added, %d removed and %d modified", added, removed, modified)
+	}
+}
+
+func TestCompareIdenticalBundles(t *testing.T) {
+	files := map[string]string{
+		"agent/config.yaml":          workload("agent:2.1.0", "INFO", ""),
+		agentPod + "info.yml":        `{"build": {"version": "2.1.0"}}`,
+		agentPod + "description.txt": description(2),
+	}
+	report := Compare(NewSnapshot(newBundle("a", files)), NewSnapshot(newBundle("b", files)))
+	if len(report.Changes) != 0 {
+		t.Errorf("got changes %+v", report.Changes)
+	}
+}
+
+func workload(image string, logLevel string, env string) string {
+	return `# Executed command: kubectl get statefulset steadybit-agent -o yaml
+
+apiVersion: apps/v1
+kind: StatefulSet
+spec:
+  template:
+    spec:
+      containers:
+      - name: steadybit-agent
+        image: ` + image + `
+        env:
+        - name: STEADYBIT_LOG_LEVEL
+          value: ` + logLevel + "\n" + env
+}
+
+func description(restarts int) string {
+	return "# Executed command: kubectl describe pod steadybit-agent-0\n\nName:  steadybit-agent-0\nContainers:\n  steadybit-agent:\n" +
+		"    State:          Running\n    Restart Count:  " + strconv.Itoa(restarts) + "\n"
+}
+
+func newBundle(name string, files map[string]string) *bundle.Bundle {
+	contents := make(map[string][]byte, len(files))
+	for p, content := range files {
+		contents[p] = []byte(content)
+	}
+	return bundle.New(name, contents)
+}
@@ -0,0 +1,319 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"path"
+	"regexp"
+	"sigs.k8s.io/yaml"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	SectionComponents = "components"
+	SectionVersions   = "versions"
+	SectionImages     = "images"
+	SectionEnv        = "env"
+	SectionRestarts   = "restarts"
+	SectionActions    = "actions"
+	SectionTargets    = "targets"
+)
+
+// Sections are compared and reported in this order
+var Sections = []string{SectionComponents, SectionVersions, SectionImages, SectionEnv, SectionRestarts, SectionActions, SectionTargets}
+
+var (
+	describedContainerPattern = regexp.MustCompile(`^  ([\w.-]+):\s*$`)
+	restartCountPattern       = regexp.MustCompile(`^\s+Restart Count:\s+(\d+)`)
+)
+
+// Snapshot holds the comparable facts of a bundle by section, component and key
+type Snapshot struct {
+	Name string
+	// Components are the names of the components in the order of the bundle
+	Components []string
+	values     map[string]map[string]map[string]map[string]bool
+}
+
+// NewSnapshot extracts the facts of all components in b
+func NewSnapshot(b *bundle.Bundle) *Snapshot {
+	s := &Snapshot{Name: b.Name, values: map[string]map[string]map[string]map[string]bool{}}
+	for _, c := range b.Components() {
+		s.Components = append(s.Components, c.Name)
+		pods := fmt.Sprintf("%d pods", len(c.Pods))
+		if len(c.Pods) == 1 {
+			pods = "1 pod"
+		}
+		s.add(SectionComponents, c.Name, c.Name, pods)
+		addWorkload(s, b, c)
+		restarts := map[string]int{}
+		for _, pod := range c.Pods {
+			addInfo(s, b, c.Name, path.Join(pod, "info.yml"))
+			addTargetStats(s, b, c.Name, path.Join(pod, "target_stats.yml"))
+			addActionIds(s, b, c.Name, path.Join(pod, "actions_metadata.yml"))
+			addExtensionIndex(s, b, c.Name, pod)
+			countRestarts(b, path.Join(pod, "description.txt"), restarts)
+		}
+		for container, count := range restarts {
+			s.add(SectionRestarts, c.Name, container, strconv.Itoa(count))
+		}
+	}
+	return s
+}
+
+// add records a value, differing values of several pods are reported together
+func (s *Snapshot) add(section string, component string, key string, value string) {
+	if s.values[section] == nil {
+		s.values[section] = map[string]map[string]map[string]bool{}
+	}
+	if s.values[section][component] == nil {
+		s.values[section][component] = map[string]map[string]bool{}
+	}
+	if s.values[section][component][key] == nil {
+		s.values[section][component][key] = map[string]bool{}
+	}
+	s.values[section][component][key][value] = true
+}
+
+// Values returns the values of a component within a section
+func (s *Snapshot) Values(section string, component string) map[string]string {
+	result := map[string]string{}
+	for key, values := range s.values[section][component] {
+		sorted := make([]string, 0, len(values))
+		for value := range values {
+			sorted = append(sorted, value)
+		}
+		sort.Strings(sorted)
+		result[key] = strings.Join(sorted, ", ")
+	}
+	return result
+}
+
+func (s *Snapshot) hasComponent(component string) bool {
+	for _, c := range s.Components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// readJson parses the output of a successfully collected artifact
+func readJson(b *bundle.Bundle, file string) (any, bool) {
+	artifact, ok := b.Artifact(file)
+	if !ok || artifact.Error() != "" {
+		return nil, false
+	}
+	var value any
+	if json.Unmarshal([]byte(artifact.Body), &value) != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+type container struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	Env   []struct {
+		Name      string          `json:"name"`
+		Value     string          `json:"value"`
+		ValueFrom json.RawMessage `json:"valueFrom"`
+	} `json:"env"`
+}
+
+// addWorkload adds the images and environment variables of the containers of the deployment, statefulset or
+// daemonset in config.yaml
+func addWorkload(s *Snapshot, b *bundle.Bundle, c bundle.Component) {
+	artifact, ok := b.Artifact(path.Join(c.Dir, "config.yaml"))
+	if !ok || artifact.Error() != "" {
+		return
+	}
+	var workload struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					InitContainers []container `json:"initContainers"`
+					Containers     []container `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if yaml.Unmarshal([]byte(artifact.Body), &workload) != nil {
+		return
+	}
+	podSpec := workload.Spec.Template.Spec
+	for _, ctr := range append(podSpec.InitContainers, podSpec.Containers...) {
+		s.add(SectionImages, c.Name, ctr.Name, ctr.Image)
+		for _, env := range ctr.Env {
+			value := env.Value
+			if len(env.ValueFrom) > 0 {
+				value = fmt.Sprintf("<from %s>", env.ValueFrom)
+			}
+			s.add(SectionEnv, c.Name, ctr.Name+"/"+env.Name, value)
+		}
+	}
+}
+
+// addInfo adds the leaves of the actuator info, e.g. 'build.version'
+func addInfo(s *Snapshot, b *bundle.Bundle, component string, file string) {
+	info, ok := readJson(b, file)
+	if !ok {
+		return
+	}
+	flatten("", info, func(key string, value any) {
+		s.add(SectionVersions, component, key, stringValue(value))
+	})
+}
+
+// addTargetStats adds the numeric leaves of the target statistics, keyed by target type
+func addTargetStats(s *Snapshot, b *bundle.Bundle, component string, file string) {
+	stats, ok := readJson(b, file)
+	if !ok {
+		return
+	}
+	flatten("", stats, func(key string, value any) {
+		if number, ok := value.(float64); ok {
+			s.add(SectionTargets, component, key, stringValue(number))
+		}
+	})
+}
+
+// addActionIds adds the ids and versions of the actions the agent knows
+func addActionIds(s *Snapshot, b *bundle.Bundle, component string, file string) {
+	metadata, ok := readJson(b, file)
+	if !ok {
+		return
+	}
+	var elements []any
+	switch value := metadata.(type) {
+	case []any:
+		elements = value
+	case map[string]any:
+		if actions, ok := value["actions"].([]any); ok {
+			elements = actions
+		}
+	}
+	for _, element := range elements {
+		if action, ok := element.(map[string]any); ok {
+			if id, ok := action["id"].(string); ok {
+				s.add(SectionActions, component, id, stringValue(action["version"]))
+			}
+		}
+	}
+}
+
+// addExtensionIndex adds the actions and discoveries an extension announces in its index, by id and version from their
+// descriptions if those were collected, otherwise by path
+func addExtensionIndex(s *Snapshot, b *bundle.Bundle, component string, pod string) {
+	for _, scheme := range []string{"http", "https"} {
+		dir := path.Join(pod, scheme)
+		index, ok := readJson(b, path.Join(dir, "GET__.yml"))
+		if !ok {
+			continue
+		}
+		fields, _ := index.(map[string]any)
+		for _, list := range []string{"actions", "discoveries"} {
+			entries, _ := fields[list].([]any)
+			for _, entry := range entries {
+				endpoint, _ := entry.(map[string]any)
+				endpointPath, _ := endpoint["path"].(string)
+				method := stringValue(endpoint["method"])
+				if endpointPath == "" {
+					continue
+				} else if method == "" {
+					method = "GET"
+				}
+				key := fmt.Sprintf("%s %s", list, endpointPath)
+				version := ""
+				file := path.Join(dir, fmt.Sprintf("%s_%s.yml", method, strings.ReplaceAll(endpointPath, "/", "_")))
+				if description, ok := readJson(b, file); ok {
+					fields, _ := description.(map[string]any)
+					if id, ok := fields["id"].(string); ok {
+						key = fmt.Sprintf("%s %s", list, id)
+						version = stringValue(fields["version"])
+					}
+				}
+				s.add(SectionActions, component, key, version)
+			}
+		}
+	}
+}
+
+// countRestarts sums the restart counts of the described pod by container
+func countRestarts(b *bundle.Bundle, file string, restarts map[string]int) {
+	artifact, ok := b.Artifact(file)
+	if !ok {
+		return
+	}
+	container := ""
+	for _, line := range artifact.Lines() {
+		if m := describedContainerPattern.FindStringSubmatch(line); m != nil {
+			container = m[1]
+		} else if m := restartCountPattern.FindStringSubmatch(line); m != nil && container != "" {
+			count, _ := strconv.Atoi(m[1])
+			restarts[container] += count
+		}
+	}
+}
+
+// flatten calls leaf for all scalar values with their dot-separated path. Array elements are keyed by their id, name
+// or type if they have one, otherwise by index.
+func flatten(prefix string, value any, leaf func(key string, value any)) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			flatten(join(key), child, leaf)
+		}
+	case []any:
+		for i, child := range v {
+			key := strconv.Itoa(i)
+			if fields, ok := child.(map[string]any); ok {
+				for _, name := range []string{"id", "name", "targetType", "type"} {
+					if id, ok := fields[name].(string); ok {
+						key = id
+						child = without(fields, name)
+						break
+					}
+				}
+			}
+			flatten(join(key), child, leaf)
+		}
+	case nil:
+	default:
+		if prefix != "" {
+			leaf(prefix, v)
+		}
+	}
+}
+
+func without(fields map[string]any, name string) map[string]any {
+	result := make(map[string]any, len(fields))
+	for key, value := range fields {
+		if key != name {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func stringValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -5,7 +5,9 @@
 package extensions
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/action-kit/go/action_kit_api/v2"
@@ -39,7 +41,7 @@ type extensionListResponse struct {
 	event_kit_api.EventListenerList `json:",inline"`
 }
 
-func TraverseExtensionEndpoints(options TraverseExtensionEndpointsOptions) {
+func TraverseExtensionEndpoints(ctx context.Context, options TraverseExtensionEndpointsOptions) {
 	baseUrl := fmt.Sprintf("http://localhost:%d/", options.Port)
 
 	podUrl, err := url.Parse(baseUrl)
@@ -47,37 +49,42 @@ func TraverseExtensionEndpoints(options TraverseExtensionEndpointsOptions) {
 		log.Debug().Msgf("Failed to parse URL '%s'", baseUrl)
 		return
 	}
-	forwardingHostWithPort, cmd, err := k8s.PreparePortforwarding(k8s.PodConfig{
+	rootArtifact := output.Artifact{
+		Path:    getOutputPath(options.PathForPod, urlsToCurl{Method: "GET", Path: "/"}),
+		Target:  fmt.Sprintf("%s/%s", options.PodNamespace, options.PodName),
+		Kind:    output.ArtifactKindHttp,
+		Command: fmt.Sprintf("GET %s", baseUrl),
+	}
+	portForward, err := k8s.AcquirePortForward(ctx, k8s.PodConfig{
 		PodNamespace: options.PodNamespace,
 		PodName:      options.PodName,
 		Config:       options.Config,
 	}, options.Port)
 	if err != nil {
 		log.Debug().Msgf("Failed to prepare port forwarding. Got error: %s", err)
+		output.RecordFailure(ctx, rootArtifact, fmt.Errorf("port-forward failed: %w", err))
 		return
 	}
 
-	defer func() {
-		k8s.KillProcess(cmd, k8s.PodConfig{
-			PodNamespace: options.PodNamespace,
-			PodName:      options.PodName,
-			Config:       options.Config,
-		})
-	}()
-
-	podUrl.Host = forwardingHostWithPort
-	body, err := output.DoHttp(output.HttpOptions{
-		Config:     options.Config,
-		Method:     "GET",
-		URL:        *podUrl,
-		UseHttps:   options.UseHttps,
-		FormatJson: false,
+	podUrl.Host = portForward.Host()
+	body, err := output.DoHttp(ctx, output.HttpOptions{
+		Config:           options.Config,
+		Method:           "GET",
+		URL:              *podUrl,
+		UseHttps:         options.UseHttps,
+		FormatJson:       false,
+		ExecutionContext: rootArtifact.Target,
 	})
+	if errors.Is(err, output.ErrDryRun) {
+		// the remaining endpoints are only known once the extension responded
+		return
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "remote error: tls: bad certificate") {
 			log.Debug().Msgf("Please provide proper TLS certificates for %s ", options.PodNamespace+"/"+options.PodName)
 		}
 		log.Debug().Msgf("Failed to get '%s'", podUrl.String())
+		output.RecordFailure(ctx, rootArtifact, err)
 		return
 	}
 
@@ -96,7 +103,7 @@ func TraverseExtensionEndpoints(options TraverseExtensionEndpointsOptions) {
 
 	for _, discovery := range extensionListResponse.Discoveries {
 		urlsToCurlSlice = append(urlsToCurlSlice, urlsToCurl{Method: string(discovery.Method), Path: discovery.Path})
-		findDiscoveredTargetsUrl(options.Config, discovery.Method, discovery.Path, podUrl, options.UseHttps, &urlsToCurlSlice)
+		findDiscoveredTargetsUrl(ctx, options.Config, discovery.Method, discovery.Path, podUrl, options.UseHttps, &urlsToCurlSlice)
 	}
 
 	for _, targetAttribute := range extensionListResponse.TargetAttributes {
@@ -119,7 +126,7 @@ func TraverseExtensionEndpoints(options TraverseExtensionEndpointsOptions) {
 		urlToCurl := urlToCurl
 		go func() {
 			defer wg.Done()
-			output.AddHttpOutput(output.AddHttpOutputOptions{
+			output.AddHttpOutput(ctx, output.AddHttpOutputOptions{
 				Config:           options.Config,
 				URL:              *fullUrl,
 				Method:           urlToCurl.Method,
@@ -140,9 +147,9 @@ func getOutputPath(pathForPod string, urlToCurl urlsToCurl) string {
 	return outputPath
 }
 
-func findDiscoveredTargetsUrl(cfg *config.Config, method discovery_kit_api.ReadHttpMethod, path string, podUrl *url.URL, useHttps bool, urlsToCurlSlicePtr *[]urlsToCurl) {
+func findDiscoveredTargetsUrl(ctx context.Context, cfg *config.Config, method discovery_kit_api.ReadHttpMethod, path string, podUrl *url.URL, useHttps bool, urlsToCurlSlicePtr *[]urlsToCurl) {
 	fullUrl := podUrl.JoinPath(path)
-	body, err := output.DoHttp(output.HttpOptions{
+	body, err := output.DoHttp(ctx, output.HttpOptions{
 		Config:     cfg,
 		Method:     string(method),
 		URL:        *fullUrl,
@@ -6,7 +6,9 @@ package extensions
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/collector"
 	"github.com/steadybit/steadybit-debug/config"
 	"github.com/steadybit/steadybit-debug/k8s"
 	appsv1 "k8s.io/api/apps/v1"
@@ -21,32 +23,51 @@ import (
 const ExtensionAutoRegistrationAnnotation = "steadybit.com/extension-auto-registration"
 const ExtensionAutoRegistrationAnnotationDeprecated = "steadybit.com/extension-auto-discovery"
 
-func AddExtensionDebuggingInformation(cfg *config.Config) {
+func init() {
+	collector.Register(collector.WithPermissions(collector.New("extensions", "Auto-registered extension services and daemon sets in all namespaces, their pods, logs and extension endpoints", nil, func(ctx context.Context, sink collector.Sink) error {
+		return AddExtensionDebuggingInformation(ctx, sink.Config())
+	}), permissions))
+}
+
+// permissions are checked across all namespaces, because extensions may be installed anywhere
+func permissions(_ *config.Config) []collector.Permission {
+	result := []collector.Permission{
+		{Verb: "list", Resource: "namespaces"},
+		{Verb: "list", Resource: "services"},
+		{Verb: "list", Group: "apps", Resource: "daemonsets"},
+		{Verb: "get", Group: "apps", Resource: "daemonsets", Optional: true},
+		{Verb: "get", Resource: "services", Optional: true},
+	}
+	result = append(result, k8s.PodPermissions("")...)
+	return append(result, k8s.PortForwardPermission(""))
+}
+
+func AddExtensionDebuggingInformation(ctx context.Context, cfg *config.Config) error {
 	var wg sync.WaitGroup
-	namespaces, err := getAllNamespaces(cfg)
+	namespaces, err := getAllNamespaces(ctx, cfg)
 	if err != nil {
-		log.Warn().Msgf("Failed to find extensions - looking up namespaces: %s", err)
-		return
+		return fmt.Errorf("failed to find extensions - looking up namespaces: %w", err)
 	}
 	if len(namespaces) == 0 {
 		log.Warn().Msgf("No namespaces found")
-		return
+		return nil
 	}
 	for _, namespace := range namespaces {
 		wg.Add(1)
 		go func(namespace string) {
 			defer wg.Done()
-			findDebugInformationInNamespace(namespace, cfg)
+			findDebugInformationInNamespace(ctx, namespace, cfg)
 		}(namespace)
 
 	}
 	wg.Wait()
+	return nil
 }
 
-func findDebugInformationInNamespace(namespace string, cfg *config.Config) {
+func findDebugInformationInNamespace(ctx context.Context, namespace string, cfg *config.Config) {
 	var wg sync.WaitGroup
 
-	services, err := findExtensionsServices(cfg, namespace)
+	services, err := findExtensionsServices(ctx, cfg, namespace)
 	if err != nil {
 		log.Warn().Msgf("Failed to find services set '%s': %s", namespace, err)
 		return
@@ -55,13 +76,13 @@ func findDebugInformationInNamespace(namespace string, cfg *config.Config) {
 		wg.Add(1)
 		go func(service v1.Service) {
 			defer wg.Done()
-			forEachPod(cfg, "service", service.Namespace, service.Name, service.Spec.Selector, func(pod *v1.Pod) []podPort {
+			forEachPod(ctx, cfg, "service", service.Namespace, service.Name, service.Spec.Selector, func(pod *v1.Pod) []podPort {
 				return identifyPodPorts(pod, service.Annotations)
 			})
 		}(service)
 	}
 
-	daemonsets, err := findExtensionDaemonsets(cfg, namespace)
+	daemonsets, err := findExtensionDaemonsets(ctx, cfg, namespace)
 	if err != nil {
 		log.Warn().Msgf("Failed to find daemonsets set '%s': %s", namespace, err)
 		return
@@ -70,7 +91,7 @@ func findDebugInformationInNamespace(namespace string, cfg *config.Config) {
 		wg.Add(1)
 		go func(daemonset appsv1.DaemonSet) {
 			defer wg.Done()
-			forEachPod(cfg, "daemonset", daemonset.Namespace, daemonset.Name, daemonset.Spec.Selector.MatchLabels, func(pod *v1.Pod) []podPort {
+			forEachPod(ctx, cfg, "daemonset", daemonset.Namespace, daemonset.Name, daemonset.Spec.Selector.MatchLabels, func(pod *v1.Pod) []podPort {
 				return identifyPodPorts(pod, pod.Annotations)
 			})
 		}(daemonset)
@@ -81,19 +102,19 @@ func findDebugInformationInNamespace(namespace string, cfg *config.Config) {
 
 type identifyPorts func(pod *v1.Pod) []podPort
 
-func forEachPod(cfg *config.Config, kind string, namespace string, name string, selector map[string]string, portsFn identifyPorts) {
+func forEachPod(ctx context.Context, cfg *config.Config, kind string, namespace string, name string, selector map[string]string, portsFn identifyPorts) {
 	pathForExtension := filepath.Join(cfg.OutputPath, "extensions", namespace, name)
-	k8s.AddDescription(cfg, filepath.Join(pathForExtension, "description.txt"), kind, namespace, name)
-	k8s.AddConfig(cfg, filepath.Join(pathForExtension, "config.yaml"), kind, namespace, name)
+	k8s.AddDescription(ctx, cfg, filepath.Join(pathForExtension, "description.txt"), kind, namespace, name)
+	k8s.AddConfig(ctx, cfg, filepath.Join(pathForExtension, "config.yaml"), kind, namespace, name)
 
-	k8s.ForEachPodViaMapSelector(cfg, namespace, selector, func(pod *v1.Pod, _ int) {
+	k8s.ForEachPodViaMapSelector(ctx, cfg, namespace, selector, func(pod *v1.Pod, _ int) {
 		pathForPod := filepath.Join(pathForExtension, "pods", pod.Name)
 
-		k8s.AddDescription(cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
-		k8s.AddConfig(cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
-		k8s.AddLogs(cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
-		k8s.AddPreviousLogs(cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
-		k8s.AddResourceUsage(cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 3)
+		k8s.AddDescription(ctx, cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
+		k8s.AddConfig(ctx, cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
+		k8s.AddLogs(ctx, cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
+		k8s.AddPreviousLogs(ctx, cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
+		k8s.AddResourceUsage(ctx, cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 3)
 
 		ports := portsFn(pod)
 		for _, port := range ports {
@@ -101,7 +122,7 @@ func forEachPod(cfg *config.Config, kind string, namespace string, name string,
 			if port.tls {
 				folderName = "https"
 			}
-			TraverseExtensionEndpoints(TraverseExtensionEndpointsOptions{
+			TraverseExtensionEndpoints(ctx, TraverseExtensionEndpointsOptions{
 				Config:       cfg,
 				PodNamespace: pod.Namespace,
 				PodName:      pod.Name,
@@ -179,12 +200,12 @@ func identifyPodPorts(pod *v1.Pod, annotations map[string]string) []podPort {
 	return []podPort{defaultPort}
 }
 
-func getAllNamespaces(cfg *config.Config) (namespaces []string, err error) {
+func getAllNamespaces(ctx context.Context, cfg *config.Config) (namespaces []string, err error) {
 	client, err := cfg.Kubernetes.Client()
 	if err != nil {
 		return nil, err
 	}
-	list, err := client.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	list, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -194,13 +215,13 @@ func getAllNamespaces(cfg *config.Config) (namespaces []string, err error) {
 	}
 	return namespaces, nil
 }
-func findExtensionsServices(cfg *config.Config, namespace string) ([]v1.Service, error) {
+func findExtensionsServices(ctx context.Context, cfg *config.Config, namespace string) ([]v1.Service, error) {
 	client, err := cfg.Kubernetes.Client()
 	if err != nil {
 		return nil, err
 	}
 
-	listOfServices, err := client.CoreV1().Services(namespace).List(context.Background(), metav1.ListOptions{})
+	listOfServices, err := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -219,13 +240,13 @@ func findExtensionsServices(cfg *config.Config, namespace string) ([]v1.Service,
 	return result, nil
 }
 
-func findExtensionDaemonsets(cfg *config.Config, namespace string) ([]appsv1.DaemonSet, error) {
+func findExtensionDaemonsets(ctx context.Context, cfg *config.Config, namespace string) ([]appsv1.DaemonSet, error) {
 	client, err := cfg.Kubernetes.Client()
 	if err != nil {
 		return nil, err
 	}
 
-	listOfDaemonsets, err := client.AppsV1().DaemonSets(namespace).List(context.Background(), metav1.ListOptions{})
+	listOfDaemonsets, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
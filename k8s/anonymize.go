@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/output"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// AddSensitiveValues registers node names and addresses, namespaces, pod and service IPs and the hostnames used by
+// platform and agent for anonymization. It fails if the cluster topology can't be listed, because artifacts would
+// otherwise contain the values in clear text.
+func AddSensitiveValues(ctx context.Context, cfg *config.Config) error {
+	client, err := cfg.Kubernetes.Client()
+	if err != nil {
+		return err
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes.Items {
+		output.AddSensitiveValue(output.AnonymizeNode, node.Name)
+		if short, _, found := strings.Cut(node.Name, "."); found {
+			output.AddSensitiveValue(output.AnonymizeNode, short)
+		}
+		for _, address := range node.Status.Addresses {
+			addAddress(address.Address, output.AnonymizeNode)
+		}
+	}
+
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	for _, namespace := range namespaces.Items {
+		output.AddSensitiveValue(output.AnonymizeNamespace, namespace.Name)
+	}
+
+	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		for _, ip := range pod.Status.PodIPs {
+			output.AddSensitiveValue(output.AnonymizeIp, ip.IP)
+		}
+		for _, ip := range pod.Status.HostIPs {
+			output.AddSensitiveValue(output.AnonymizeIp, ip.IP)
+		}
+		if pod.Namespace == cfg.Platform.Namespace || pod.Namespace == cfg.Agent.Namespace {
+			addHostsFromEnv(pod.Spec.Containers)
+		}
+	}
+
+	services, err := client.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, service := range services.Items {
+		for _, ip := range append(service.Spec.ClusterIPs, service.Spec.ExternalIPs...) {
+			addAddress(ip, output.AnonymizeHost)
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			addAddress(ingress.IP, output.AnonymizeHost)
+			addAddress(ingress.Hostname, output.AnonymizeHost)
+		}
+	}
+
+	// ingresses are optional, the platform may be exposed differently
+	ingresses, err := client.NetworkingV1().Ingresses(cfg.Platform.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Debug().Msgf("Failed to list ingresses of namespace '%s' for anonymization. Got error: %s", cfg.Platform.Namespace, err)
+		return nil
+	}
+	for _, ingress := range ingresses.Items {
+		for _, rule := range ingress.Spec.Rules {
+			addAddress(rule.Host, output.AnonymizeHost)
+		}
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				addAddress(host, output.AnonymizeHost)
+			}
+		}
+	}
+	return nil
+}
+
+// addAddress registers IPs as such and everything else with the given category
+func addAddress(address string, category string) {
+	if net.ParseIP(address) != nil {
+		output.AddSensitiveValue(output.AnonymizeIp, address)
+	} else if address != "None" {
+		output.AddSensitiveValue(category, address)
+	}
+}
+
+// addHostsFromEnv registers the hosts of URLs in environment variables, e.g. STEADYBIT_AGENT_REGISTER_URL
+func addHostsFromEnv(containers []v1.Container) {
+	for _, container := range containers {
+		for _, env := range container.Env {
+			u, err := url.Parse(env.Value)
+			if err != nil || u.Host == "" || u.Scheme == "" {
+				continue
+			}
+			host := u.Hostname()
+			// cluster-internal hosts consist of service, namespace and cluster domain, which are anonymized on their own
+			if strings.Contains(host, ".svc.") || strings.HasSuffix(host, ".svc") || !strings.Contains(host, ".") && net.ParseIP(host) == nil {
+				continue
+			}
+			addAddress(host, output.AnonymizeHost)
+		}
+	}
+}
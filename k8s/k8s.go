@@ -4,28 +4,27 @@
 package k8s
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/connectivity"
 	"github.com/steadybit/steadybit-debug/output"
+	"github.com/steadybit/steadybit-debug/scheduler"
 	"io"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"net/url"
-	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-func FindDeployment(cfg *config.Config, namespace string, name string) (*appsv1.Deployment, error) {
+func FindDeployment(ctx context.Context, cfg *config.Config, namespace string, name string) (*appsv1.Deployment, error) {
 	client, err := cfg.Kubernetes.Client()
 	if err != nil {
 		return nil, err
@@ -34,10 +33,10 @@ func FindDeployment(cfg *config.Config, namespace string, name string) (*appsv1.
 	return client.
 		AppsV1().
 		Deployments(namespace).
-		Get(context.Background(), name, metav1.GetOptions{})
+		Get(ctx, name, metav1.GetOptions{})
 }
 
-func FindStatefulSet(cfg *config.Config, namespace string, name string) (*appsv1.StatefulSet, error) {
+func FindStatefulSet(ctx context.Context, cfg *config.Config, namespace string, name string) (*appsv1.StatefulSet, error) {
 	client, err := cfg.Kubernetes.Client()
 	if err != nil {
 		return nil, err
@@ -46,76 +45,99 @@ func FindStatefulSet(cfg *config.Config, namespace string, name string) (*appsv1
 	return client.
 		AppsV1().
 		StatefulSets(namespace).
-		Get(context.Background(), name, metav1.GetOptions{})
+		Get(ctx, name, metav1.GetOptions{})
 }
 
-func AddDescription(config *config.Config, outputPath string, kind string, namespace string, name string) {
-	log.Debug().Msgf("Adding description for '%s' in namespace '%s' to '%s'", name, namespace, outputPath)
-	output.AddCommandOutput(context.Background(), output.AddCommandOutputOptions{
-		Config:           config,
-		CommandName:      "kubectl",
-		CommandArgs:      []string{"describe", kind, "-n", namespace, name},
-		OutputPath:       outputPath,
-		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
-		LogError:         true,
-	})
-}
-
-func AddHttpConnectionTest(config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
+func AddHttpConnectionTest(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
 	log.Debug().Msgf("Adding http connection test via curl for '%s' in namespace '%s' to '%s'", name, namespace, outputPath)
-	addWithEphemeralContainer(context.Background(), config, outputPath, namespace, name, containerName, config.Agent.CurlImage, "curl", []string{"-v", url}, nil)
+	addConnectionTest(ctx, config, outputPath, namespace, name, containerName, config.Agent.CurlImage, "curl", []string{"-v", url}, nil, addCurlVerdict)
 }
 
-func AddTracerouteConnectionTest(config *config.Config, outputPath string, namespace string, name string, containerName string, host string) {
+func AddTracerouteConnectionTest(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, host string) {
 	log.Debug().Msgf("Adding traceroute connection test for '%s' in namespace '%s' to '%s'", name, namespace, outputPath)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
-	addWithEphemeralContainer(ctx, config, outputPath, namespace, name, containerName, config.Agent.TracerouteImage, "traceroute", []string{host}, nil)
+	addConnectionTest(ctx, config, outputPath, namespace, name, containerName, config.Agent.TracerouteImage, "traceroute", []string{host}, nil, nil)
 }
 
-func AddWebsocketCurlHttp1ConnectionTest(config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
+func AddWebsocketCurlHttp1ConnectionTest(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
 	log.Debug().Msgf("Adding curl http1 connection test via curl for '%s' in namespace '%s' to '%s'", name, namespace, outputPath)
-	addWithEphemeralContainer(context.Background(), config, outputPath, namespace, name, containerName, config.Agent.CurlImage, "curl", []string{"-v", "--http1.1", url + "/ws", "-H", "upgrade: websocket", "-H", "connection: Upgrade", "-H", "sec-websocket-key: dummy", "-H", "sec-websocket-Version: 13", "-v", "--http1.1"}, nil)
+	addConnectionTest(ctx, config, outputPath, namespace, name, containerName, config.Agent.CurlImage, "curl", []string{"-v", "--http1.1", url + "/ws", "-H", "upgrade: websocket", "-H", "connection: Upgrade", "-H", "sec-websocket-key: dummy", "-H", "sec-websocket-Version: 13", "-v", "--http1.1"}, nil, addCurlVerdict)
 }
 
-func AddWebsocketCurlHttp2ConnectionTest(config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
+func AddWebsocketCurlHttp2ConnectionTest(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
 	log.Debug().Msgf("Adding curl http2 connection test via curl for '%s' in namespace '%s' to '%s'", name, namespace, outputPath)
-	addWithEphemeralContainer(context.Background(), config, outputPath, namespace, name, containerName, config.Agent.CurlImage, "curl", []string{"-v", "--http1.1", url + "/ws", "-H", "upgrade: websocket", "-H", "connection: Upgrade", "-H", "sec-websocket-key: dummy", "-H", "sec-websocket-Version: 13", "-v"}, nil)
+	addConnectionTest(ctx, config, outputPath, namespace, name, containerName, config.Agent.CurlImage, "curl", []string{"-v", "--http1.1", url + "/ws", "-H", "upgrade: websocket", "-H", "connection: Upgrade", "-H", "sec-websocket-key: dummy", "-H", "sec-websocket-Version: 13", "-v"}, nil, addCurlVerdict)
 }
 
-func AddWebsocketWebsocatConnectionTest(config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
+func AddWebsocketWebsocatConnectionTest(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, url string) {
 	log.Debug().Msgf("Adding websocat connection test for '%s' in namespace '%s' to '%s'", name, namespace, outputPath)
 	wsUrl := strings.ReplaceAll(url, "https://", "wss://")
 	wsUrl = strings.ReplaceAll(wsUrl, "http://", "ws://")
-	addWithEphemeralContainer(context.Background(), config, outputPath, namespace, name, containerName, config.Agent.WebsocatImage, "websocat", []string{wsUrl + "/ws", "-v"}, strings.NewReader(" "))
+	addConnectionTest(ctx, config, outputPath, namespace, name, containerName, config.Agent.WebsocatImage, "websocat", []string{wsUrl + "/ws", "-v"}, strings.NewReader(" "), nil)
 }
 
-func addWithEphemeralContainer(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, imageName string, command string, args []string, stdin io.Reader) {
+// addConnectionTest runs the command from the network namespace of the target pod (ephemeral container) or from a
+// probe pod next to it, depending on the configured probe mode. onOutput may interpret the output, see addCurlVerdict.
+func addConnectionTest(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, imageName string, command string, args []string, stdin io.Reader, onOutput func(string, []byte)) {
+	if config.ReadOnly {
+		reason := "read-only mode, requires an ephemeral container"
+		if config.Agent.ProbeMode == ProbeModePod {
+			reason = "read-only mode, requires a probe pod"
+		}
+		output.Skip(ctx, output.Artifact{
+			Path:    outputPath,
+			Target:  fmt.Sprintf("%s/%s", namespace, name),
+			Kind:    output.ArtifactKindCommand,
+			Command: fmt.Sprintf("%s %s", command, strings.Join(args, " ")),
+		}, reason)
+		return
+	}
+	if config.Agent.ProbeMode == ProbeModePod {
+		addWithProbePod(ctx, config, outputPath, namespace, name, containerName, imageName, command, args, stdin, onOutput)
+	} else {
+		addWithEphemeralContainer(ctx, config, outputPath, namespace, name, containerName, imageName, command, args, stdin, onOutput)
+	}
+}
+
+func addWithEphemeralContainer(ctx context.Context, config *config.Config, outputPath string, namespace string, name string, containerName string, imageName string, command string, args []string, stdin io.Reader, onOutput func(string, []byte)) {
 	commandArgs := []string{"debug", "-it", name, "-n", namespace, "--target", containerName, "--image", imageName, "-c", "steadybit-debug-" + strconv.Itoa(int(time.Now().Unix())), "--", command}
 	commandArgs = append(commandArgs, args...)
+	if output.DryRun() {
+		output.AddPlannedStep(ctx, output.PlannedStep{
+			Target:     fmt.Sprintf("%s/%s", namespace, name),
+			Kind:       output.PlanKindEphemeralContainer,
+			Command:    fmt.Sprintf("kubectl %s", strings.Join(commandArgs, " ")),
+			Image:      imageName,
+			OutputPath: outputPath,
+		})
+		return
+	}
 	output.AddCommandOutput(ctx, output.AddCommandOutputOptions{
 		Config:           config,
 		CommandName:      "kubectl",
 		CommandArgs:      commandArgs,
 		OutputPath:       outputPath,
 		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
+		OnOutput:         onOutput,
 	})
 }
 
-func AddConfig(config *config.Config, outputPath string, kind string, namespace string, name string) {
-	output.AddCommandOutput(context.Background(), output.AddCommandOutputOptions{
-		Config:           config,
-		CommandName:      "kubectl",
-		CommandArgs:      []string{"get", kind, "-n", namespace, "-o", "yaml", name},
-		OutputPath:       outputPath,
-		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
-		LogError:         true,
-	})
+// addCurlVerdict stores the interpretation of a 'curl -v' connection test next to it and records it for the summary
+func addCurlVerdict(outputPath string, out []byte) {
+	verdict := connectivity.ParseCurl(string(out))
+	content, err := json.MarshalIndent(verdict, "", "\t")
+	if err != nil {
+		log.Debug().Msgf("Failed to serialize the verdict of '%s': %s", outputPath, err)
+		return
+	}
+	output.WriteToFile(connectivity.VerdictPath(outputPath), content)
+	connectivity.Record(outputPath, verdict)
 }
 
 // ForEachPod note that the function fn will be executed in parallel for each pod
-func ForEachPod(cfg *config.Config, namespace string, selector *metav1.LabelSelector, fn func(pod *v1.Pod, idx int)) {
-	podList, err := findPods(cfg, namespace, selector)
+func ForEachPod(ctx context.Context, cfg *config.Config, namespace string, selector *metav1.LabelSelector, fn func(pod *v1.Pod, idx int)) {
+	podList, err := findPods(ctx, cfg, namespace, selector)
 	if err != nil {
 		log.Debug().Msgf("Failed to find pods in namespace '%s' for selector '%s'. Got error: %s", namespace, selector.String(), err)
 		return
@@ -125,8 +147,8 @@ func ForEachPod(cfg *config.Config, namespace string, selector *metav1.LabelSele
 }
 
 // ForEachPodViaMapSelector note that the function fn will be executed in parallel for each pod
-func ForEachPodViaMapSelector(cfg *config.Config, namespace string, selectorMap map[string]string, fn func(pod *v1.Pod, idx int)) {
-	podList, err := findPodsBySelectorMap(cfg, namespace, selectorMap)
+func ForEachPodViaMapSelector(ctx context.Context, cfg *config.Config, namespace string, selectorMap map[string]string, fn func(pod *v1.Pod, idx int)) {
+	podList, err := findPodsBySelectorMap(ctx, cfg, namespace, selectorMap)
 	if err != nil {
 		log.Debug().Msgf("Failed to find pods in namespace '%s' for selector '%s'. Got error: %s", namespace, selectorMap, err)
 		return
@@ -150,7 +172,8 @@ func doWithPods(podList *v1.PodList, fn func(pod *v1.Pod, idx int)) {
 	wg.Wait()
 }
 
-func findPods(cfg *config.Config,
+func findPods(ctx context.Context,
+	cfg *config.Config,
 	namespace string,
 	selector *metav1.LabelSelector) (*v1.PodList, error) {
 	selectorMap, err := metav1.LabelSelectorAsMap(selector)
@@ -158,10 +181,10 @@ func findPods(cfg *config.Config,
 		return nil, err
 	}
 
-	return findPodsBySelectorMap(cfg, namespace, selectorMap)
+	return findPodsBySelectorMap(ctx, cfg, namespace, selectorMap)
 }
 
-func findPodsBySelectorMap(cfg *config.Config, namespace string, selectorMap map[string]string) (*v1.PodList, error) {
+func findPodsBySelectorMap(ctx context.Context, cfg *config.Config, namespace string, selectorMap map[string]string) (*v1.PodList, error) {
 	client, err := cfg.Kubernetes.Client()
 	if err != nil {
 		return nil, err
@@ -170,13 +193,13 @@ func findPodsBySelectorMap(cfg *config.Config, namespace string, selectorMap map
 	return client.
 		CoreV1().
 		Pods(namespace).
-		List(context.Background(), metav1.ListOptions{
+		List(ctx, metav1.ListOptions{
 			LabelSelector: labels.SelectorFromSet(selectorMap).String(),
 		})
 }
 
 // ForEachNode note that the function fn will be executed in parallel for each node
-func ForEachNode(cfg *config.Config, fn func(node *v1.Node)) {
+func ForEachNode(ctx context.Context, cfg *config.Config, fn func(node *v1.Node)) {
 	client, err := cfg.Kubernetes.Client()
 	if err != nil {
 		log.Debug().Msgf("Failed to create Kubernetes client while trying to find node information. Got error: %s", err)
@@ -186,7 +209,7 @@ func ForEachNode(cfg *config.Config, fn func(node *v1.Node)) {
 	nodeList, err := client.
 		CoreV1().
 		Nodes().
-		List(context.Background(), metav1.ListOptions{})
+		List(ctx, metav1.ListOptions{})
 	if err != nil {
 		log.Debug().Msgf("Failed to find nodes. Got error: %s", err)
 		return
@@ -205,44 +228,6 @@ func ForEachNode(cfg *config.Config, fn func(node *v1.Node)) {
 	wg.Wait()
 }
 
-func AddLogs(cfg *config.Config, path string, namespace string, name string) {
-	log.Debug().Msgf("Adding logs for '%s' in namespace '%s' to '%s'", name, namespace, path)
-	output.AddCommandOutput(context.Background(), output.AddCommandOutputOptions{
-		Config:           cfg,
-		CommandName:      "kubectl",
-		CommandArgs:      []string{"logs", "-n", namespace, "--all-containers", name},
-		OutputPath:       path,
-		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
-		LogError:         true,
-	})
-}
-
-func AddPreviousLogs(cfg *config.Config, path string, namespace string, name string) {
-	log.Debug().Msgf("Adding previous logs for '%s' in namespace '%s' to '%s'", name, namespace, path)
-	output.AddCommandOutput(context.Background(), output.AddCommandOutputOptions{
-		Config:           cfg,
-		CommandName:      "kubectl",
-		CommandArgs:      []string{"logs", "-n", namespace, "--previous", "--all-containers", name},
-		OutputPath:       path,
-		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
-	})
-}
-
-// AddResourceUsage path must include '%d' to replace the execution number within the file path
-func AddResourceUsage(cfg *config.Config, path string, namespace string, name string, executions int) {
-	log.Debug().Msgf("Adding resource usage for '%s' in namespace '%s' to '%s'", name, namespace, path)
-	delay := time.Millisecond * 500
-	output.AddCommandOutput(context.Background(), output.AddCommandOutputOptions{
-		Config:                 cfg,
-		CommandName:            "kubectl",
-		CommandArgs:            []string{"top", "pod", "-n", namespace, "--containers", name},
-		OutputPath:             path,
-		Executions:             executions,
-		DelayBetweenExecutions: &delay,
-		ExecutionContext:       fmt.Sprintf("%s/%s", namespace, name),
-	})
-}
-
 type EndpointsOutputOptions struct {
 	OutputPath             string
 	Url                    string
@@ -280,22 +265,17 @@ type AddDownloadOutputOptions struct {
 	Method       string
 }
 
-func AddPodHttpMultipleEndpointOutput(options AddPodHttpEndpointsOutputOptions) {
+func AddPodHttpMultipleEndpointOutput(ctx context.Context, options AddPodHttpEndpointsOutputOptions) {
 	log.Debug().Msgf("Adding multiple http endpoints for '%s' in namespace '%s'", options.PodConfig.PodName, options.PodConfig.PodNamespace)
-	forwardingHostWithPort, cmd, err := PreparePortforwarding(PodConfig{
-		PodNamespace: options.PodConfig.PodNamespace,
-		PodName:      options.PodConfig.PodName,
-		Config:       options.PodConfig.Config,
-	}, options.SharedPort)
+	portForward, err := AcquirePortForward(ctx, options.PodConfig, options.SharedPort)
 	if err != nil {
 		log.Debug().Msgf("Failed to prepare port forwarding. Got error: %s", err)
+		for _, endpoint := range options.EndpointOptions {
+			recordPortForwardFailure(ctx, options.PodConfig, endpoint.OutputPath, endpoint.Executions, endpoint.Url, err)
+		}
 		return
 	}
 
-	defer func() {
-		KillProcess(cmd, options.PodConfig)
-	}()
-
 	var wg sync.WaitGroup
 	for _, endpoint := range options.EndpointOptions {
 		wg.Add(1)
@@ -306,15 +286,16 @@ func AddPodHttpMultipleEndpointOutput(options AddPodHttpEndpointsOutputOptions)
 				log.Debug().Msgf("Failed to parse URL '%s'", endpoint.Url)
 				return
 			}
-			podUrl.Host = forwardingHostWithPort
+			podUrl.Host = portForward.Host()
 
-			output.AddCommandOutput(context.Background(), output.AddCommandOutputOptions{
+			output.AddCommandOutput(ctx, output.AddCommandOutputOptions{
 				Config:                 options.PodConfig.Config,
 				OutputPath:             endpoint.OutputPath,
 				Executions:             endpoint.Executions,
 				DelayBetweenExecutions: endpoint.DelayBetweenExecutions,
 				CommandName:            "curl",
 				CommandArgs:            []string{"-s", podUrl.String()},
+				Resources:              []scheduler.Resource{scheduler.Http},
 				ExecutionContext:       fmt.Sprintf("%s/%s", options.PodConfig.PodNamespace, options.PodConfig.PodName),
 			})
 		}(endpoint)
@@ -329,30 +310,26 @@ type Connection struct {
 	Method string
 }
 
-func GetExtensionConnections(sharedPort int, podConfig PodConfig, cfg *config.Config) []Connection {
+func GetExtensionConnections(ctx context.Context, sharedPort int, podConfig PodConfig, cfg *config.Config) []Connection {
 	log.Debug().Msgf("Getting extension connections for '%s' in namespace '%s'", podConfig.PodName, podConfig.PodNamespace)
-	forwardingHostWithPort, cmd, err := PreparePortforwarding(podConfig, sharedPort)
+	portForward, err := AcquirePortForward(ctx, podConfig, sharedPort)
 	if err != nil {
 		log.Debug().Msgf("Failed to prepare port forwarding. Got error: %s", err)
 		return nil
 	}
 
-	defer func() {
-		KillProcess(cmd, podConfig)
-	}()
-
-	podUrl, err := url.Parse(fmt.Sprintf("http://localhost:%d/extension/connections", sharedPort))
+	podUrl, err := url.Parse(fmt.Sprintf("http://%s/extension/connections", portForward.Host()))
 	if err != nil {
-		log.Debug().Msgf("Failed to parse URL '%s'", forwardingHostWithPort)
+		log.Debug().Msgf("Failed to parse URL '%s'", portForward.Host())
 		return nil
 	}
-	podUrl.Host = forwardingHostWithPort
 	log.Debug().Msgf("Using URL '%s' for extension connection test", podUrl.String())
-	body, err := output.DoHttp(output.HttpOptions{
-		Config:     cfg,
-		Method:     "GET",
-		URL:        *podUrl,
-		FormatJson: false,
+	body, err := output.DoHttp(ctx, output.HttpOptions{
+		Config:           cfg,
+		Method:           "GET",
+		URL:              *podUrl,
+		FormatJson:       false,
+		ExecutionContext: fmt.Sprintf("%s/%s", podConfig.PodNamespace, podConfig.PodName),
 	})
 	if err != nil {
 		log.Debug().Msgf("Failed to read response body")
@@ -367,37 +344,31 @@ func GetExtensionConnections(sharedPort int, podConfig PodConfig, cfg *config.Co
 	return connections
 
 }
-func AddPodHttpEndpointOutput(options AddPodHttpEndpointOutputOptions) {
+func AddPodHttpEndpointOutput(ctx context.Context, options AddPodHttpEndpointOutputOptions) {
 	podUrl, err := url.Parse(options.Url)
 	if err != nil {
 		log.Debug().Msgf("Failed to parse URL '%s'", options.Url)
 		return
 	}
 	port, _ := strconv.Atoi(podUrl.Port())
-	host, cmd, err := PreparePortforwarding(PodConfig{
+	portForward, err := AcquirePortForward(ctx, PodConfig{
 		PodNamespace: options.PodNamespace,
 		PodName:      options.PodName,
 		Config:       options.Config,
 	}, port)
 	if err != nil {
 		log.Debug().Msgf("Failed to prepare port forwarding. Got error: %s", err)
+		recordPortForwardFailure(ctx, PodConfig{PodNamespace: options.PodNamespace, PodName: options.PodName}, options.OutputPath, options.Executions, options.Url, err)
 		return
 	}
 
-	defer func() {
-		KillProcess(cmd, PodConfig{
-			PodNamespace: options.PodNamespace,
-			PodName:      options.PodName,
-			Config:       options.Config,
-		})
-	}()
-
-	podUrl.Host = host
+	podUrl.Host = portForward.Host()
 
-	output.AddCommandOutput(context.Background(), output.AddCommandOutputOptions{
+	output.AddCommandOutput(ctx, output.AddCommandOutputOptions{
 		Config:                 options.Config,
 		CommandName:            "curl",
 		CommandArgs:            []string{"-s", podUrl.String()},
+		Resources:              []scheduler.Resource{scheduler.Http},
 		OutputPath:             options.OutputPath,
 		Executions:             options.Executions,
 		DelayBetweenExecutions: options.DelayBetweenExecutions,
@@ -405,7 +376,7 @@ func AddPodHttpEndpointOutput(options AddPodHttpEndpointOutputOptions) {
 	})
 }
 
-func DownloadFromPod(options AddDownloadOutputOptions) {
+func DownloadFromPod(ctx context.Context, options AddDownloadOutputOptions) {
 	log.Debug().Msgf("Downloading from '%s' in namespace '%s'", options.PodName, options.PodNamespace)
 	downloadUrl, err := url.Parse(options.Url)
 	if err != nil {
@@ -413,61 +384,47 @@ func DownloadFromPod(options AddDownloadOutputOptions) {
 		return
 	}
 	port, _ := strconv.Atoi(downloadUrl.Port())
-	forwardingHostWithPort, cmd, err := PreparePortforwarding(PodConfig{
+	portForward, err := AcquirePortForward(ctx, PodConfig{
 		PodNamespace: options.PodNamespace,
 		PodName:      options.PodName,
 		Config:       options.Config,
 	}, port)
 	if err != nil {
 		log.Debug().Msgf("Failed to prepare port forwarding. Got error: %s", err)
+		output.RecordFailure(ctx, output.Artifact{
+			Path:    options.OutputPath,
+			Target:  fmt.Sprintf("%s/%s", options.PodNamespace, options.PodName),
+			Kind:    output.ArtifactKindDownload,
+			Command: fmt.Sprintf("%s %s", options.Method, options.Url),
+		}, fmt.Errorf("port-forward failed: %w", err))
 		return
 	}
 
-	defer func() {
-		KillProcess(cmd, PodConfig{
-			PodNamespace: options.PodNamespace,
-			PodName:      options.PodName,
-			Config:       options.Config,
-		})
-	}()
-
-	downloadUrl.Host = forwardingHostWithPort
-	output.DownloadOutput(output.DownloadOptions{
-		Config:     options.Config,
-		OutputPath: options.OutputPath,
-		Method:     options.Method,
-		URL:        *downloadUrl,
+	downloadUrl.Host = portForward.Host()
+	output.DownloadOutput(ctx, output.DownloadOptions{
+		Config:           options.Config,
+		OutputPath:       options.OutputPath,
+		Method:           options.Method,
+		URL:              *downloadUrl,
+		ExecutionContext: fmt.Sprintf("%s/%s", options.PodNamespace, options.PodName),
 	})
 }
 
-func KillProcess(cmd *exec.Cmd, options PodConfig) {
-	err := cmd.Process.Kill()
-	if err != nil {
-		log.Debug().Msgf("Failed to stop port-forward for '%s' in namespace '%s", options.PodName, options.PodNamespace)
-		return
-	}
-}
-
-func PreparePortforwarding(options PodConfig, port int) (string, *exec.Cmd, error) {
-	cmd := exec.Command("kubectl", "port-forward", "-n", options.PodNamespace, fmt.Sprintf("pod/%s", options.PodName), fmt.Sprintf(":%d", port))
-	log.Debug().Msgf("Executing: %s", cmd.String())
-
-	cmdOut, _ := cmd.StdoutPipe()
-	err := cmd.Start()
-	if err != nil {
-		log.Debug().Msgf("Failed to port-forward for '%s' in namespace '%s", options.PodName, options.PodNamespace)
-		return "", nil, err
-	}
-
-	var result string
-	var r = regexp.MustCompile("^Forwarding from .+:(\\d+) -> \\d+$")
-	scanner := bufio.NewScanner(cmdOut)
-	for scanner.Scan() {
-		m := r.FindStringSubmatch(scanner.Text())
-		if m != nil {
-			result = fmt.Sprintf("localhost:%s", m[1])
-			break
+// recordPortForwardFailure adds the endpoint output that could not be collected to the manifest
+func recordPortForwardFailure(ctx context.Context, podConfig PodConfig, outputPath string, executions int, url string, err error) {
+	paths := []string{outputPath}
+	if executions > 1 {
+		paths = make([]string, 0, executions)
+		for i := 0; i < executions; i++ {
+			paths = append(paths, fmt.Sprintf(outputPath, i))
 		}
 	}
-	return result, cmd, nil
+	for _, path := range paths {
+		output.RecordFailure(ctx, output.Artifact{
+			Path:    path,
+			Target:  fmt.Sprintf("%s/%s", podConfig.PodNamespace, podConfig.PodName),
+			Kind:    output.ArtifactKindCommand,
+			Command: fmt.Sprintf("curl -s %s", url),
+		}, fmt.Errorf("port-forward failed: %w", err))
+	}
 }
@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/output"
+	"io"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"strings"
+	"time"
+)
+
+func AddLogs(ctx context.Context, cfg *config.Config, path string, namespace string, name string) {
+	log.Debug().Msgf("Adding logs for '%s' in namespace '%s' to '%s'", name, namespace, path)
+	addLogs(ctx, cfg, path, namespace, name, false, true)
+}
+
+func AddPreviousLogs(ctx context.Context, cfg *config.Config, path string, namespace string, name string) {
+	log.Debug().Msgf("Adding previous logs for '%s' in namespace '%s' to '%s'", name, namespace, path)
+	addLogs(ctx, cfg, path, namespace, name, true, false)
+}
+
+func addLogs(ctx context.Context, cfg *config.Config, path string, namespace string, name string, previous bool, logError bool) {
+	request := fmt.Sprintf("logs -n %s --all-containers %s", namespace, name)
+	if previous {
+		request = fmt.Sprintf("logs -n %s --previous --all-containers %s", namespace, name)
+	}
+	window := cfg.Logs.Window(output.CollectorFrom(ctx))
+	request += logWindowArgs(window)
+
+	output.AddApiOutput(ctx, output.AddApiOutputOptions{
+		Config:           cfg,
+		Request:          request,
+		OutputPath:       path,
+		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
+		LogError:         logError,
+		Truncatable:      true,
+		Fn: func(ctx context.Context, w io.Writer) error {
+			client, err := cfg.Kubernetes.Client()
+			if err != nil {
+				return err
+			}
+			streamingClient, err := cfg.Kubernetes.StreamingClient()
+			if err != nil {
+				return err
+			}
+
+			pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			var errs []error
+			for _, container := range allContainerNames(pod) {
+				fmt.Fprintf(w, "# Container: %s\n", container)
+				err := streamContainerLogs(ctx, streamingClient, namespace, name, podLogOptions(container, previous, window), window, w)
+				if err != nil {
+					fmt.Fprintf(w, "# Resulted in error: %s\n", err)
+					errs = append(errs, fmt.Errorf("container %s: %w", container, err))
+				}
+				fmt.Fprintln(w)
+			}
+			return errors.Join(errs...)
+		},
+	})
+}
+
+func podLogOptions(container string, previous bool, window config.LogWindow) *v1.PodLogOptions {
+	opts := &v1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+		// the API has no upper bound, lines after --until are dropped based on their timestamp
+		Timestamps: !window.Until.IsZero(),
+	}
+	if window.Since > 0 {
+		seconds := int64(window.Since.Duration().Seconds())
+		opts.SinceSeconds = &seconds
+	} else if !window.SinceTime.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: window.SinceTime.Time()}
+	}
+	// the server applies the tail to the whole log, with --until it is applied after dropping the newer lines instead
+	if tail := window.TailLines(); tail > 0 && window.Until.IsZero() {
+		opts.TailLines = &tail
+	}
+	return opts
+}
+
+// logWindowArgs returns the window like kubectl logs arguments, e.g. ' --since=2h0m0s --tail=1000'
+func logWindowArgs(window config.LogWindow) string {
+	var args strings.Builder
+	if window.Since > 0 {
+		fmt.Fprintf(&args, " --since=%s", window.Since)
+	} else if !window.SinceTime.IsZero() {
+		fmt.Fprintf(&args, " --since-time=%s", window.SinceTime)
+	}
+	if !window.Until.IsZero() {
+		fmt.Fprintf(&args, " --until=%s", window.Until)
+	}
+	if tail := window.TailLines(); tail > 0 {
+		fmt.Fprintf(&args, " --tail=%d", tail)
+	}
+	return args.String()
+}
+
+func streamContainerLogs(ctx context.Context, client kubernetes.Interface, namespace string, name string, opts *v1.PodLogOptions, window config.LogWindow, w io.Writer) error {
+	stream, err := client.CoreV1().Pods(namespace).GetLogs(name, opts).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if window.Until.IsZero() {
+		_, err = io.Copy(w, stream)
+		return err
+	}
+	return copyUntil(stream, w, window.Until.Time(), window.TailLines())
+}
+
+// copyUntil copies log lines prefixed with their timestamp until the first line after until, and strips the timestamps.
+// With tail > 0, only the last tail lines before until are copied.
+func copyUntil(r io.Reader, w io.Writer, until time.Time, tail int64) error {
+	lines := &lineRing{size: tail}
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			timestamp, text, found := strings.Cut(line, " ")
+			if t, parseErr := time.Parse(time.RFC3339Nano, timestamp); parseErr == nil && found {
+				if t.After(until) {
+					return lines.flush(w)
+				}
+				line = text
+			}
+			if writeErr := lines.add(w, line); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err == io.EOF {
+			return lines.flush(w)
+		} else if err != nil {
+			return errors.Join(lines.flush(w), err)
+		}
+	}
+}
+
+// lineRing keeps the last size lines until they are flushed, or passes all lines through if size is 0
+type lineRing struct {
+	size  int64
+	lines []string
+	next  int
+}
+
+func (r *lineRing) add(w io.Writer, line string) error {
+	if r.size <= 0 {
+		_, err := io.WriteString(w, line)
+		return err
+	}
+	if int64(len(r.lines)) < r.size {
+		r.lines = append(r.lines, line)
+		return nil
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	return nil
+}
+
+func (r *lineRing) flush(w io.Writer) error {
+	for i := range r.lines {
+		if _, err := io.WriteString(w, r.lines[(r.next+i)%len(r.lines)]); err != nil {
+			return err
+		}
+	}
+	r.lines, r.next = nil, 0
+	return nil
+}
+
+// allContainerNames mirrors the container order of `kubectl logs --all-containers`
+func allContainerNames(pod *v1.Pod) []string {
+	names := make([]string, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers)+len(pod.Spec.EphemeralContainers))
+	for _, container := range pod.Spec.InitContainers {
+		names = append(names, container.Name)
+	}
+	for _, container := range pod.Spec.Containers {
+		names = append(names, container.Name)
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		names = append(names, container.Name)
+	}
+	return names
+}
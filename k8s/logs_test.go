@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"github.com/steadybit/steadybit-debug/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyUntil(t *testing.T) {
+	log := "2026-01-02T10:00:00Z one\n" +
+		"2026-01-02T10:01:00Z two\n" +
+		"2026-01-02T10:02:00.5Z three\n" +
+		"continuation without timestamp\n" +
+		"2026-01-02T10:03:00Z four\n" +
+		"2026-01-02T10:04:00Z five\n"
+	until := time.Date(2026, 1, 2, 10, 3, 30, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		tail  int64
+		until time.Time
+		want  string
+	}{
+		{name: "all lines before until", until: until, want: "one\ntwo\nthree\ncontinuation without timestamp\nfour\n"},
+		{name: "tail is applied after until", tail: 2, until: until, want: "continuation without timestamp\nfour\n"},
+		{name: "tail larger than the lines", tail: 10, until: until, want: "one\ntwo\nthree\ncontinuation without timestamp\nfour\n"},
+		{name: "until before all lines", tail: 2, until: until.Add(-time.Hour), want: ""},
+		{name: "until after all lines", tail: 1, until: until.Add(time.Hour), want: "five\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := copyUntil(strings.NewReader(log), &out, tt.until, tt.tail); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPodLogOptionsTail(t *testing.T) {
+	tail := int64(100)
+	until, err := time.Parse(time.RFC3339, "2026-01-02T10:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := podLogOptions("main", false, config.LogWindow{Tail: &tail})
+	if opts.TailLines == nil || *opts.TailLines != tail {
+		t.Errorf("expected the tail to be sent to the server without until, got %v", opts.TailLines)
+	}
+	opts = podLogOptions("main", false, config.LogWindow{Tail: &tail, Until: config.Timestamp(until)})
+	if opts.TailLines != nil {
+		t.Errorf("expected no tail to be sent to the server with until, got %d", *opts.TailLines)
+	}
+}
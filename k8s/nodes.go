@@ -4,17 +4,34 @@
 package k8s
 
 import (
+	"context"
+	"github.com/steadybit/steadybit-debug/collector"
 	"github.com/steadybit/steadybit-debug/config"
 	v1 "k8s.io/api/core/v1"
 	"path/filepath"
 )
 
-func AddKubernetesNodesInformation(cfg *config.Config) {
+func init() {
+	collector.Register(collector.WithPermissions(collector.New("nodes", "Kubernetes node descriptions and configurations", nil, func(ctx context.Context, sink collector.Sink) error {
+		AddKubernetesNodesInformation(ctx, sink.Config())
+		return nil
+	}), func(*config.Config) []collector.Permission {
+		return []collector.Permission{
+			{Verb: "list", Resource: "nodes"},
+			{Verb: "get", Resource: "nodes"},
+			// kubectl describe node lists the pods and events of the node across all namespaces
+			{Verb: "list", Resource: "pods", Optional: true},
+			{Verb: "list", Resource: "events", Optional: true},
+		}
+	}))
+}
+
+func AddKubernetesNodesInformation(ctx context.Context, cfg *config.Config) {
 	pathForNodes := filepath.Join(cfg.OutputPath, "nodes")
 
-	ForEachNode(cfg, func(node *v1.Node) {
+	ForEachNode(ctx, cfg, func(node *v1.Node) {
 		pathForNode := filepath.Join(pathForNodes, node.Name)
-		AddDescription(cfg, filepath.Join(pathForNode, "description.txt"), "node", node.Namespace, node.Name)
-		AddConfig(cfg, filepath.Join(pathForNode, "config.yaml"), "node", node.Namespace, node.Name)
+		AddDescription(ctx, cfg, filepath.Join(pathForNode, "description.txt"), "node", node.Namespace, node.Name)
+		AddConfig(ctx, cfg, filepath.Join(pathForNode, "config.yaml"), "node", node.Namespace, node.Name)
 	})
 }
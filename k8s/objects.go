@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/output"
+	"io"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/describe"
+	"sigs.k8s.io/yaml"
+	"sync"
+)
+
+type objectKind struct {
+	gvk schema.GroupVersionKind
+	get func(ctx context.Context, client kubernetes.Interface, namespace string, name string) (runtime.Object, error)
+}
+
+// objectKinds lists the kinds that can be described or exported, keyed by the lower-case kind name used throughout the collectors.
+var objectKinds = map[string]objectKind{
+	"deployment": {
+		gvk: appsv1.SchemeGroupVersion.WithKind("Deployment"),
+		get: func(ctx context.Context, client kubernetes.Interface, namespace string, name string) (runtime.Object, error) {
+			return client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"statefulset": {
+		gvk: appsv1.SchemeGroupVersion.WithKind("StatefulSet"),
+		get: func(ctx context.Context, client kubernetes.Interface, namespace string, name string) (runtime.Object, error) {
+			return client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"daemonset": {
+		gvk: appsv1.SchemeGroupVersion.WithKind("DaemonSet"),
+		get: func(ctx context.Context, client kubernetes.Interface, namespace string, name string) (runtime.Object, error) {
+			return client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"service": {
+		gvk: v1.SchemeGroupVersion.WithKind("Service"),
+		get: func(ctx context.Context, client kubernetes.Interface, namespace string, name string) (runtime.Object, error) {
+			return client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"pod": {
+		gvk: v1.SchemeGroupVersion.WithKind("Pod"),
+		get: func(ctx context.Context, client kubernetes.Interface, namespace string, name string) (runtime.Object, error) {
+			return client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+	"node": {
+		gvk: v1.SchemeGroupVersion.WithKind("Node"),
+		get: func(ctx context.Context, client kubernetes.Interface, _ string, name string) (runtime.Object, error) {
+			return client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		},
+	},
+}
+
+func lookupKind(kind string) (objectKind, error) {
+	k, ok := objectKinds[kind]
+	if !ok {
+		return objectKind{}, fmt.Errorf("unsupported kind '%s'", kind)
+	}
+	return k, nil
+}
+
+var (
+	describersMu sync.Mutex
+	describers   = map[schema.GroupKind]describe.ResourceDescriber{}
+)
+
+// describerFor caches the describers as each of them creates its own set of clients
+func describerFor(config *config.Config, groupKind schema.GroupKind) (describe.ResourceDescriber, error) {
+	describersMu.Lock()
+	defer describersMu.Unlock()
+
+	if describer, ok := describers[groupKind]; ok {
+		return describer, nil
+	}
+
+	restConfig, err := config.Kubernetes.RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	describer, ok := describe.DescriberFor(groupKind, restConfig)
+	if !ok {
+		return nil, fmt.Errorf("no describer available for kind '%s'", groupKind.Kind)
+	}
+	describers[groupKind] = describer
+	return describer, nil
+}
+
+func AddDescription(ctx context.Context, config *config.Config, outputPath string, kind string, namespace string, name string) {
+	log.Debug().Msgf("Adding description for '%s' in namespace '%s' to '%s'", name, namespace, outputPath)
+	output.AddApiOutput(ctx, output.AddApiOutputOptions{
+		Config:           config,
+		Request:          fmt.Sprintf("describe %s -n %s %s", kind, namespace, name),
+		OutputPath:       outputPath,
+		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
+		LogError:         true,
+		Fn: func(ctx context.Context, w io.Writer) error {
+			k, err := lookupKind(kind)
+			if err != nil {
+				return err
+			}
+
+			describer, err := describerFor(config, k.gvk.GroupKind())
+			if err != nil {
+				return err
+			}
+
+			description, err := describer.Describe(namespace, name, describe.DescriberSettings{ShowEvents: true, ChunkSize: 500})
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, description)
+			return err
+		},
+	})
+}
+
+func AddConfig(ctx context.Context, config *config.Config, outputPath string, kind string, namespace string, name string) {
+	output.AddApiOutput(ctx, output.AddApiOutputOptions{
+		Config:           config,
+		Request:          fmt.Sprintf("get %s -n %s -o yaml %s", kind, namespace, name),
+		OutputPath:       outputPath,
+		ExecutionContext: fmt.Sprintf("%s/%s", namespace, name),
+		LogError:         true,
+		Fn: func(ctx context.Context, w io.Writer) error {
+			k, err := lookupKind(kind)
+			if err != nil {
+				return err
+			}
+
+			client, err := config.Kubernetes.Client()
+			if err != nil {
+				return err
+			}
+
+			obj, err := k.get(ctx, client, namespace, name)
+			if err != nil {
+				return err
+			}
+
+			// typed clients drop the type information and kubectl hides managed fields by default
+			obj.GetObjectKind().SetGroupVersionKind(k.gvk)
+			if accessor, ok := obj.(metav1.Object); ok {
+				accessor.SetManagedFields(nil)
+			}
+
+			content, err := yaml.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(content)
+			return err
+		},
+	})
+}
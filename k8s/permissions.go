@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"github.com/steadybit/steadybit-debug/collector"
+	"github.com/steadybit/steadybit-debug/config"
+)
+
+// WorkloadPermissions are needed to find a workload of the given resource, e.g. deployments, and describe it
+func WorkloadPermissions(namespace string, resource string) []collector.Permission {
+	return []collector.Permission{
+		{Verb: "get", Group: "apps", Resource: resource, Namespace: namespace},
+		{Verb: "list", Resource: "events", Namespace: namespace, Optional: true},
+	}
+}
+
+// PodPermissions are needed to find the pods of a workload, describe them, read their logs and resource usage
+func PodPermissions(namespace string) []collector.Permission {
+	return []collector.Permission{
+		{Verb: "list", Resource: "pods", Namespace: namespace},
+		{Verb: "get", Resource: "pods", Namespace: namespace},
+		{Verb: "get", Resource: "pods", Subresource: "log", Namespace: namespace},
+		{Verb: "get", Group: "metrics.k8s.io", Resource: "pods", Namespace: namespace, Optional: true},
+	}
+}
+
+// PortForwardPermission is needed to request HTTP endpoints of pods
+func PortForwardPermission(namespace string) collector.Permission {
+	return collector.Permission{Verb: "create", Resource: "pods", Subresource: "portforward", Namespace: namespace, Optional: true}
+}
+
+// ConnectionTestPermissions are needed for the connection tests of the configured probe mode
+func ConnectionTestPermissions(cfg *config.Config, namespace string) []collector.Permission {
+	switch {
+	case cfg.ReadOnly:
+		return nil
+	case cfg.Agent.ProbeMode == ProbeModePod:
+		return []collector.Permission{
+			{Verb: "create", Resource: "pods", Namespace: namespace, Optional: true},
+			{Verb: "delete", Resource: "pods", Namespace: namespace, Optional: true},
+			{Verb: "create", Resource: "pods", Subresource: "exec", Namespace: namespace, Optional: true},
+			{Verb: "list", Group: "networking.k8s.io", Resource: "networkpolicies", Namespace: namespace, Optional: true},
+		}
+	default:
+		return []collector.Permission{
+			{Verb: "patch", Resource: "pods", Subresource: "ephemeralcontainers", Namespace: namespace, Optional: true},
+			{Verb: "create", Resource: "pods", Subresource: "attach", Namespace: namespace, Optional: true},
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/output"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const portForwardReadyTimeout = 30 * time.Second
+const portForwardCloseTimeout = 5 * time.Second
+
+// PortForward is an in-process port-forward to a single pod port. Local connections are accepted on 127.0.0.1.
+type PortForward struct {
+	Namespace  string
+	PodName    string
+	RemotePort int
+	LocalPort  int
+
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// StartPortForward opens a port-forward to the given pod port and blocks until the forward is ready to accept
+// connections, the timeout elapsed or the context got cancelled.
+func StartPortForward(ctx context.Context, options PodConfig, port int) (*PortForward, error) {
+	restConfig, err := options.Config.Kubernetes.RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := options.Config.Kubernetes.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	req := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(options.PodNamespace).
+		Name(options.PodName).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+	// prefer websockets like kubectl does and fall back to SPDY for API servers not supporting it
+	tunnelingDialer, err := portforward.NewSPDYOverWebsocketDialer(req.URL(), restConfig)
+	if err != nil {
+		return nil, err
+	}
+	dialer = portforward.NewFallbackDialer(tunnelingDialer, dialer, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+
+	pf := &PortForward{
+		Namespace:  options.PodNamespace,
+		PodName:    options.PodName,
+		RemotePort: port,
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+	readyCh := make(chan struct{})
+	logOutput := &portForwardLogWriter{pf: pf}
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", port)}, pf.stopCh, readyCh, logOutput, logOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().Msgf("Starting port-forward to port %d of '%s' in namespace '%s'", port, options.PodName, options.PodNamespace)
+	go func() {
+		defer close(pf.doneCh)
+		pf.err = forwarder.ForwardPorts()
+	}()
+
+	timer := time.NewTimer(portForwardReadyTimeout)
+	defer timer.Stop()
+	select {
+	case <-readyCh:
+		ports, err := forwarder.GetPorts()
+		if err != nil || len(ports) == 0 {
+			pf.Close()
+			return nil, fmt.Errorf("port-forward to %s did not report a local port: %v", pf, err)
+		}
+		pf.LocalPort = int(ports[0].Local)
+		log.Debug().Msgf("Port-forward %s listening on %s", pf, pf.Host())
+		return pf, nil
+	case <-pf.doneCh:
+		if pf.err == nil {
+			pf.err = errors.New("port-forward terminated before it got ready")
+		}
+		return nil, fmt.Errorf("port-forward to %s failed: %w", pf, pf.err)
+	case <-timer.C:
+		pf.Close()
+		return nil, fmt.Errorf("port-forward to %s did not get ready within %s", pf, portForwardReadyTimeout)
+	case <-ctx.Done():
+		pf.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Host returns the local host and port to use in URLs
+func (pf *PortForward) Host() string {
+	return fmt.Sprintf("127.0.0.1:%d", pf.LocalPort)
+}
+
+// Done is closed when the port-forward terminated, e.g. because the connection to the pod got lost
+func (pf *PortForward) Done() <-chan struct{} {
+	return pf.doneCh
+}
+
+// Err returns the reason for termination once Done is closed
+func (pf *PortForward) Err() error {
+	select {
+	case <-pf.doneCh:
+		return pf.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the port-forward and waits for the listeners to shut down. It is safe to call Close multiple times.
+func (pf *PortForward) Close() {
+	pf.closeOnce.Do(func() {
+		close(pf.stopCh)
+	})
+	select {
+	case <-pf.doneCh:
+	case <-time.After(portForwardCloseTimeout):
+		log.Debug().Msgf("Port-forward %s did not shut down within %s", pf, portForwardCloseTimeout)
+	}
+}
+
+func (pf *PortForward) String() string {
+	return fmt.Sprintf("%s/%s:%d", pf.Namespace, pf.PodName, pf.RemotePort)
+}
+
+type portForwardLogWriter struct {
+	pf *PortForward
+}
+
+func (w *portForwardLogWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSpace(string(p)), "\n") {
+		if line != "" {
+			log.Debug().Msgf("Port-forward %s: %s", w.pf, line)
+		}
+	}
+	return len(p), nil
+}
+
+// portForwardPool shares port-forwards between collectors so that each pod port is forwarded at most once per run
+type portForwardPool struct {
+	mu       sync.Mutex
+	forwards map[string]*pooledPortForward
+	// closed is set by ClosePortForwards, no port-forwards are started afterwards
+	closed bool
+}
+
+type pooledPortForward struct {
+	ready chan struct{}
+	pf    *PortForward
+	err   error
+}
+
+var portForwards = &portForwardPool{forwards: map[string]*pooledPortForward{}}
+
+var errPortForwardsClosed = errors.New("port-forwards are already closed")
+
+// AcquirePortForward returns the pooled port-forward for the given pod port and starts one if none is running yet.
+// Pooled port-forwards must not be closed by the caller, they are stopped via ClosePortForwards. It fails once
+// ClosePortForwards was called.
+func AcquirePortForward(ctx context.Context, options PodConfig, port int) (*PortForward, error) {
+	key := fmt.Sprintf("%s/%s/%d", options.PodNamespace, options.PodName, port)
+
+	for {
+		portForwards.mu.Lock()
+		if portForwards.closed {
+			portForwards.mu.Unlock()
+			return nil, errPortForwardsClosed
+		}
+		entry, ok := portForwards.forwards[key]
+		if !ok {
+			entry = &pooledPortForward{ready: make(chan struct{})}
+			portForwards.forwards[key] = entry
+			portForwards.mu.Unlock()
+
+			if output.DryRun() {
+				entry.pf = planPortForward(ctx, options, port)
+			} else {
+				entry.pf, entry.err = StartPortForward(ctx, options, port)
+			}
+			close(entry.ready)
+			if entry.err != nil {
+				// don't keep failures around, the next collector may try again
+				portForwards.remove(key, entry)
+				return nil, entry.err
+			}
+			if portForwards.isClosed() {
+				// ClosePortForwards stops the port-forward started meanwhile
+				return nil, errPortForwardsClosed
+			}
+			return entry.pf, nil
+		}
+		portForwards.mu.Unlock()
+
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		if portForwards.isClosed() {
+			return nil, errPortForwardsClosed
+		}
+		select {
+		case <-entry.pf.Done():
+			log.Debug().Msgf("Pooled port-forward %s terminated (%v), starting a new one", entry.pf, entry.pf.Err())
+			portForwards.remove(key, entry)
+		default:
+			return entry.pf, nil
+		}
+	}
+}
+
+// planPortForward records the port-forward in the dry-run plan and returns a placeholder using the remote port as local port
+func planPortForward(ctx context.Context, options PodConfig, port int) *PortForward {
+	pf := &PortForward{
+		Namespace:  options.PodNamespace,
+		PodName:    options.PodName,
+		RemotePort: port,
+		LocalPort:  port,
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+	go func() {
+		<-pf.stopCh
+		close(pf.doneCh)
+	}()
+	output.AddPlannedStep(ctx, output.PlannedStep{
+		Target:  fmt.Sprintf("%s/%s", options.PodNamespace, options.PodName),
+		Kind:    output.PlanKindPortForward,
+		Command: fmt.Sprintf("port-forward pod/%s -n %s :%d", options.PodName, options.PodNamespace, port),
+	})
+	return pf
+}
+
+// ClosePortForwards stops all pooled port-forwards, including those still starting
+func ClosePortForwards() {
+	portForwards.mu.Lock()
+	entries := portForwards.forwards
+	portForwards.forwards = map[string]*pooledPortForward{}
+	portForwards.closed = true
+	portForwards.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		go func(entry *pooledPortForward) {
+			defer wg.Done()
+			<-entry.ready
+			if entry.pf != nil {
+				entry.pf.Close()
+			}
+		}(entry)
+	}
+	wg.Wait()
+	log.Debug().Msgf("Closed %d pooled port-forwards", len(entries))
+}
+
+func (p *portForwardPool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func (p *portForwardPool) remove(key string, entry *pooledPortForward) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.forwards[key] == entry {
+		delete(p.forwards, key)
+	}
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAcquirePortForwardAfterClose(t *testing.T) {
+	defer func() { portForwards = &portForwardPool{forwards: map[string]*pooledPortForward{}} }()
+
+	ClosePortForwards()
+	pf, err := AcquirePortForward(context.Background(), PodConfig{PodNamespace: "steadybit-agent", PodName: "steadybit-agent-0"}, 42899)
+	if !errors.Is(err, errPortForwardsClosed) || pf != nil {
+		t.Errorf("got %v, %v after ClosePortForwards", pf, err)
+	}
+	if len(portForwards.forwards) != 0 {
+		t.Errorf("port-forward pooled after ClosePortForwards")
+	}
+}
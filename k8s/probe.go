@@ -0,0 +1,425 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/output"
+	"io"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Probe modes of the agent connection tests, see config.AgentConfig.ProbeMode
+const (
+	ProbeModeEphemeralContainer = "ephemeral-container"
+	ProbeModePod                = "probe-pod"
+)
+
+const (
+	probePodLabel          = "steadybit.com/debug-probe"
+	probePodReadyTimeout   = 2 * time.Minute
+	probePodDeleteTimeout  = 30 * time.Second
+	probePodActiveDeadline = 2 * time.Hour
+)
+
+// missingSleepPattern matches the start error of a probe container whose image has no sleep binary, e.g.
+// exec: "sleep": executable file not found in $PATH
+var missingSleepPattern = regexp.MustCompile(`"?sleep"?: (executable file not found|no such file or directory)`)
+
+// probeContainerFailureReasons are reasons of waiting containers that won't start without changes to the pod. Images
+// that can't be pulled, e.g. in air-gapped clusters, only fail the tests using them instead of the whole probe pod.
+var probeContainerFailureReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"CreateContainerError":       true,
+	"CreateContainerConfigError": true,
+	"ErrImageNeverPull":          true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"RunContainerError":          true,
+	"StartError":                 true,
+}
+
+// proxyEnvNames are copied from the target container, so that probes take the same route to the platform
+var proxyEnvNames = map[string]bool{
+	"HTTP_PROXY":  true,
+	"HTTPS_PROXY": true,
+	"NO_PROXY":    true,
+	"http_proxy":  true,
+	"https_proxy": true,
+	"no_proxy":    true,
+}
+
+// probePodPool creates at most one probe pod per target pod and run
+type probePodPool struct {
+	mu   sync.Mutex
+	pods map[string]*probePod
+}
+
+type probePod struct {
+	ready     chan struct{}
+	namespace string
+	name      string
+	// containers maps images to the name of the container running them
+	containers map[string]string
+	err        error
+}
+
+var probePods = &probePodPool{pods: map[string]*probePod{}}
+
+// addWithProbePod runs the command in a short-lived pod next to the target pod instead of an ephemeral container within it
+func addWithProbePod(ctx context.Context, cfg *config.Config, outputPath string, namespace string, name string, containerName string, imageName string, command string, args []string, stdin io.Reader, onOutput func(string, []byte)) {
+	target := fmt.Sprintf("%s/%s", namespace, name)
+	commandArgs := func(probeName string, probeContainer string) []string {
+		result := []string{"exec", probeName, "-n", namespace, "-c", probeContainer}
+		if stdin != nil {
+			result = append(result, "-i")
+		}
+		return append(append(result, "--", command), args...)
+	}
+
+	if output.DryRun() {
+		output.AddPlannedStep(ctx, output.PlannedStep{
+			Target:     target,
+			Kind:       output.PlanKindProbePod,
+			Command:    fmt.Sprintf("kubectl %s", strings.Join(commandArgs(probePodName(name), probeContainerName(imageName)), " ")),
+			Image:      imageName,
+			OutputPath: outputPath,
+		})
+		return
+	}
+
+	probe := acquireProbePod(ctx, cfg, namespace, name, containerName)
+	if probe.err != nil {
+		output.RecordFailure(ctx, output.Artifact{
+			Path:    outputPath,
+			Target:  target,
+			Kind:    output.ArtifactKindCommand,
+			Command: fmt.Sprintf("kubectl %s", strings.Join(commandArgs(probePodName(name), probeContainerName(imageName)), " ")),
+		}, fmt.Errorf("probe pod failed: %w", probe.err))
+		return
+	}
+	probeContainer := probe.containers[imageName]
+	if problem := probe.containerProblem(ctx, cfg, probeContainer); problem != "" {
+		output.Skip(ctx, output.Artifact{
+			Path:    outputPath,
+			Target:  target,
+			Kind:    output.ArtifactKindCommand,
+			Command: fmt.Sprintf("kubectl %s", strings.Join(commandArgs(probe.name, probeContainer), " ")),
+		}, problem)
+		return
+	}
+	output.AddCommandOutput(ctx, output.AddCommandOutputOptions{
+		Config:           cfg,
+		CommandName:      "kubectl",
+		CommandArgs:      commandArgs(probe.name, probeContainer),
+		OutputPath:       outputPath,
+		ExecutionContext: target,
+		Stdin:            stdin,
+		OnOutput:         onOutput,
+	})
+}
+
+func acquireProbePod(ctx context.Context, cfg *config.Config, namespace string, name string, containerName string) *probePod {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	probePods.mu.Lock()
+	probe, ok := probePods.pods[key]
+	if !ok {
+		probe = &probePod{ready: make(chan struct{})}
+		probePods.pods[key] = probe
+		probePods.mu.Unlock()
+		probe.err = createProbePod(ctx, cfg, probe, namespace, name, containerName)
+		close(probe.ready)
+		return probe
+	}
+	probePods.mu.Unlock()
+
+	select {
+	case <-probe.ready:
+		return probe
+	case <-ctx.Done():
+		return &probePod{err: context.Cause(ctx)}
+	}
+}
+
+// createProbePod starts a pod on the node of the target pod with its service account, NetworkPolicy labels, proxy env,
+// network and security settings, so that NetworkPolicies, routes and PodSecurity admission apply to it like they do to
+// the target
+func createProbePod(ctx context.Context, cfg *config.Config, probe *probePod, namespace string, name string, containerName string) error {
+	client, err := cfg.Kubernetes.Client()
+	if err != nil {
+		return err
+	}
+	target, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	var env []v1.EnvVar
+	for _, container := range target.Spec.Containers {
+		if container.Name != containerName {
+			continue
+		}
+		for _, e := range container.Env {
+			if proxyEnvNames[e.Name] {
+				env = append(env, e)
+			}
+		}
+	}
+
+	labels := networkPolicyLabels(ctx, client, target)
+	labels[probePodLabel] = "true"
+
+	var securityContext *v1.SecurityContext
+	for _, container := range target.Spec.Containers {
+		if container.Name == containerName && container.SecurityContext != nil {
+			securityContext = container.SecurityContext.DeepCopy()
+		}
+	}
+
+	probe.containers = map[string]string{}
+	var containers []v1.Container
+	for _, image := range []string{cfg.Agent.CurlImage, cfg.Agent.TracerouteImage, cfg.Agent.WebsocatImage} {
+		if _, ok := probe.containers[image]; ok {
+			continue
+		}
+		containerName := probeContainerName(image)
+		for _, c := range containers {
+			if c.Name == containerName {
+				containerName = fmt.Sprintf("%s-%d", containerName, len(containers))
+			}
+		}
+		probe.containers[image] = containerName
+		containers = append(containers, v1.Container{
+			Name:            containerName,
+			Image:           image,
+			Command:         []string{"sleep", strconv.Itoa(int(probePodActiveDeadline.Seconds()))},
+			Env:             env,
+			SecurityContext: securityContext,
+			// never ready, so that services selecting the target's labels don't route traffic to the probe
+			ReadinessProbe: &v1.Probe{ProbeHandler: v1.ProbeHandler{Exec: &v1.ExecAction{Command: []string{"false"}}}},
+		})
+	}
+
+	controller := true
+	automountToken := false
+	activeDeadline := int64(probePodActiveDeadline.Seconds())
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      probePodName(name),
+			Namespace: namespace,
+			Labels:    labels,
+			// a controller reference keeps workload controllers from adopting the probe and garbage collects it with the target
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       target.Name,
+				UID:        target.UID,
+				Controller: &controller,
+			}},
+		},
+		Spec: v1.PodSpec{
+			NodeName:                     target.Spec.NodeName,
+			ServiceAccountName:           target.Spec.ServiceAccountName,
+			AutomountServiceAccountToken: &automountToken,
+			HostNetwork:                  target.Spec.HostNetwork,
+			DNSPolicy:                    target.Spec.DNSPolicy,
+			DNSConfig:                    target.Spec.DNSConfig,
+			HostAliases:                  target.Spec.HostAliases,
+			ImagePullSecrets:             target.Spec.ImagePullSecrets,
+			Tolerations:                  target.Spec.Tolerations,
+			SecurityContext:              target.Spec.SecurityContext,
+			RestartPolicy:                v1.RestartPolicyNever,
+			ActiveDeadlineSeconds:        &activeDeadline,
+			Containers:                   containers,
+		},
+	}
+
+	log.Debug().Msgf("Creating probe pod '%s' in namespace '%s' on node '%s'", pod.Name, namespace, pod.Spec.NodeName)
+	created, err := client.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	probe.namespace = created.Namespace
+	probe.name = created.Name
+
+	err = wait.PollUntilContextTimeout(ctx, time.Second, probePodReadyTimeout, true, func(ctx context.Context) (bool, error) {
+		current, err := client.CoreV1().Pods(namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		terminated := current.Status.Phase == v1.PodFailed || current.Status.Phase == v1.PodSucceeded
+		if terminated && len(current.Status.ContainerStatuses) == 0 {
+			return false, fmt.Errorf("probe pod '%s' terminated: %s", created.Name, current.Status.Message)
+		}
+		// a single container failing to start, e.g. for lack of sleep, only affects the tests using its image
+		for _, container := range probe.containers {
+			if _, pending := probeContainerState(current, container); pending {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("probe pod '%s' did not start: %w", created.Name, err)
+	}
+	return nil
+}
+
+// networkPolicyLabels returns the labels of the target that NetworkPolicies select pods by, either as the pods a policy
+// applies to or as peers of its rules. Other labels are left out, so that services, PodDisruptionBudgets or monitors
+// selecting the target don't pick up the probe. Policies of all namespaces are considered if they can be listed, as
+// peers may be selected by policies of other namespaces.
+func networkPolicyLabels(ctx context.Context, client kubernetes.Interface, target *v1.Pod) map[string]string {
+	labels := map[string]string{}
+	policies, err := client.NetworkingV1().NetworkPolicies("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		policies, err = client.NetworkingV1().NetworkPolicies(target.Namespace).List(ctx, metav1.ListOptions{})
+	}
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to list NetworkPolicies, the probe pod next to '%s' gets none of its labels and NetworkPolicies may apply differently to it", target.Name)
+		return labels
+	}
+
+	keys := map[string]bool{}
+	addKeys := func(selector *metav1.LabelSelector) {
+		if selector == nil {
+			return
+		}
+		for key := range selector.MatchLabels {
+			keys[key] = true
+		}
+		for _, expression := range selector.MatchExpressions {
+			keys[expression.Key] = true
+		}
+	}
+	for _, policy := range policies.Items {
+		addKeys(&policy.Spec.PodSelector)
+		for _, rule := range policy.Spec.Ingress {
+			for _, peer := range rule.From {
+				addKeys(peer.PodSelector)
+			}
+		}
+		for _, rule := range policy.Spec.Egress {
+			for _, peer := range rule.To {
+				addKeys(peer.PodSelector)
+			}
+		}
+	}
+	for key, value := range target.Labels {
+		if keys[key] {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// containerProblem returns why commands can't be run in the container of the probe pod, or "" if it is running
+func (p *probePod) containerProblem(ctx context.Context, cfg *config.Config, container string) string {
+	client, err := cfg.Kubernetes.Client()
+	if err != nil {
+		return ""
+	}
+	pod, err := client.CoreV1().Pods(p.namespace).Get(ctx, p.name, metav1.GetOptions{})
+	if err != nil {
+		// kubectl exec reports the problem in the output
+		log.Debug().Msgf("Failed to check probe pod '%s': %s", p.name, err)
+		return ""
+	}
+	problem, _ := probeContainerState(pod, container)
+	return problem
+}
+
+// probeContainerState returns why commands can't be run in the container, or "" if it is running. pending is true
+// while the container is still starting.
+func probeContainerState(pod *v1.Pod, container string) (problem string, pending bool) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != container {
+			continue
+		}
+		switch {
+		case status.State.Running != nil:
+			return "", false
+		case status.State.Terminated != nil:
+			terminated := status.State.Terminated
+			if missingSleepPattern.MatchString(terminated.Message) || terminated.ExitCode == 127 {
+				return "probe image has no sleep", false
+			}
+			return strings.TrimSpace(fmt.Sprintf("probe container terminated: %s %s", terminated.Reason, terminated.Message)), false
+		case status.State.Waiting != nil && probeContainerFailureReasons[status.State.Waiting.Reason]:
+			waiting := status.State.Waiting
+			if missingSleepPattern.MatchString(waiting.Message) {
+				return "probe image has no sleep", false
+			}
+			return strings.TrimSpace(fmt.Sprintf("probe container did not start: %s %s", waiting.Reason, waiting.Message)), false
+		}
+		return fmt.Sprintf("probe container is not running: %s", pod.Status.Phase), true
+	}
+	return fmt.Sprintf("probe container is not running: %s", pod.Status.Phase), true
+}
+
+func probePodName(targetName string) string {
+	suffix := "-probe-" + strconv.FormatInt(time.Now().Unix(), 36)
+	name := "steadybit-debug-" + targetName
+	if len(name)+len(suffix) > 63 {
+		name = strings.TrimRight(name[:63-len(suffix)], "-.")
+	}
+	return name + suffix
+}
+
+// probeContainerName derives the container name from the image, e.g. curlimages/curl:8.1 -> curl
+func probeContainerName(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	name, _, _ = strings.Cut(name, ":")
+	name, _, _ = strings.Cut(name, "@")
+	return name
+}
+
+// DeleteProbePods deletes all probe pods created during the run. It works even if the run got cancelled.
+func DeleteProbePods(cfg *config.Config) {
+	probePods.mu.Lock()
+	probes := probePods.pods
+	probePods.pods = map[string]*probePod{}
+	probePods.mu.Unlock()
+	if len(probes) == 0 {
+		return
+	}
+
+	client, err := cfg.Kubernetes.Client()
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to delete probe pods")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), probePodDeleteTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, probe := range probes {
+		wg.Add(1)
+		go func(probe *probePod) {
+			defer wg.Done()
+			<-probe.ready
+			if probe.name == "" {
+				return
+			}
+			gracePeriod := int64(0)
+			err := client.CoreV1().Pods(probe.namespace).Delete(ctx, probe.name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+			if err != nil {
+				log.Warn().Err(err).Msgf("Failed to delete probe pod '%s' in namespace '%s', please delete it manually", probe.name, probe.namespace)
+			}
+		}(probe)
+	}
+	wg.Wait()
+	log.Debug().Msgf("Deleted %d probe pods", len(probes))
+}
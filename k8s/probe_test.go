@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"testing"
+)
+
+func TestProbeContainerState(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       *v1.ContainerState
+		wantProblem string
+		wantPending bool
+	}{
+		{name: "running", state: &v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+		{name: "creating", state: &v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+			wantProblem: "probe container is not running: Running", wantPending: true},
+		{name: "no status yet", wantProblem: "probe container is not running: Running", wantPending: true},
+		{name: "containerd start error", state: &v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+			Reason:   "StartError",
+			ExitCode: 128,
+			Message:  `failed to create containerd task: failed to create shim task: OCI runtime create failed: runc create failed: unable to start container process: exec: "sleep": executable file not found in $PATH: unknown`,
+		}}, wantProblem: "probe image has no sleep"},
+		{name: "waiting to run", state: &v1.ContainerState{Waiting: &v1.ContainerStateWaiting{
+			Reason:  "RunContainerError",
+			Message: `exec: "sleep": executable file not found in $PATH`,
+		}}, wantProblem: "probe image has no sleep"},
+		{name: "command not found", state: &v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 127}},
+			wantProblem: "probe image has no sleep"},
+		{name: "other termination", state: &v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			wantProblem: "probe container terminated: OOMKilled"},
+		{name: "image pull failed", state: &v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImagePull", Message: `failed to pull and unpack image "docker.io/solsson/websocat:latest": dial tcp: i/o timeout`}},
+			wantProblem: `probe container did not start: ErrImagePull failed to pull and unpack image "docker.io/solsson/websocat:latest": dial tcp: i/o timeout`},
+		{name: "image pull back-off", state: &v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: `Back-off pulling image "docker.io/solsson/websocat:latest"`}},
+			wantProblem: `probe container did not start: ImagePullBackOff Back-off pulling image "docker.io/solsson/websocat:latest"`},
+		{name: "image never pulled", state: &v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImageNeverPull", Message: `Container image "traceroute:1.0" is not present with pull policy of Never`}},
+			wantProblem: `probe container did not start: ErrImageNeverPull Container image "traceroute:1.0" is not present with pull policy of Never`},
+		{name: "config error", state: &v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CreateContainerConfigError", Message: "container has runAsNonRoot and image will run as root"}},
+			wantProblem: "probe container did not start: CreateContainerConfigError container has runAsNonRoot and image will run as root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{Status: v1.PodStatus{Phase: v1.PodRunning}}
+			if tt.state != nil {
+				pod.Status.ContainerStatuses = []v1.ContainerStatus{
+					{Name: "other", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+					{Name: "curl", State: *tt.state},
+				}
+			}
+			problem, pending := probeContainerState(pod, "curl")
+			if problem != tt.wantProblem || pending != tt.wantPending {
+				t.Errorf("got (%q, %v), want (%q, %v)", problem, pending, tt.wantProblem, tt.wantPending)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/output"
+	"io"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+	"text/tabwriter"
+	"time"
+)
+
+// AddResourceUsage path must include '%d' to replace the execution number within the file path
+func AddResourceUsage(ctx context.Context, cfg *config.Config, path string, namespace string, name string, executions int) {
+	log.Debug().Msgf("Adding resource usage for '%s' in namespace '%s' to '%s'", name, namespace, path)
+	delay := time.Millisecond * 500
+	output.AddApiOutput(ctx, output.AddApiOutputOptions{
+		Config:                 cfg,
+		Request:                fmt.Sprintf("top pod -n %s --containers %s", namespace, name),
+		OutputPath:             path,
+		Executions:             executions,
+		DelayBetweenExecutions: &delay,
+		ExecutionContext:       fmt.Sprintf("%s/%s", namespace, name),
+		Fn: func(ctx context.Context, w io.Writer) error {
+			restConfig, err := cfg.Kubernetes.RestConfig()
+			if err != nil {
+				return err
+			}
+			httpClient, err := cfg.Kubernetes.HttpClient()
+			if err != nil {
+				return err
+			}
+			client, err := metrics.NewForConfigAndClient(restConfig, httpClient)
+			if err != nil {
+				return err
+			}
+
+			podMetrics, err := client.MetricsV1beta1().PodMetricses(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			// same layout as `kubectl top pod --containers`
+			tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+			fmt.Fprintln(tw, "POD\tNAME\tCPU(cores)\tMEMORY(bytes)")
+			for _, container := range podMetrics.Containers {
+				cpu := container.Usage[v1.ResourceCPU]
+				memory := container.Usage[v1.ResourceMemory]
+				fmt.Fprintf(tw, "%s\t%s\t%dm\t%dMi\n", podMetrics.Name, container.Name, cpu.MilliValue(), memory.Value()/(1024*1024))
+			}
+			return tw.Flush()
+		},
+	})
+}
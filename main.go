@@ -4,52 +4,287 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/collector"
 	"github.com/steadybit/steadybit-debug/config"
 	"github.com/steadybit/steadybit-debug/debugrun"
+	"github.com/steadybit/steadybit-debug/k8s"
 	"github.com/steadybit/steadybit-debug/output"
+	"github.com/steadybit/steadybit-debug/preflight"
+	"github.com/steadybit/steadybit-debug/scheduler"
+	"github.com/steadybit/steadybit-debug/versions"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 )
 
 func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	cfg := config.GetConfig()
+	cfg, command, args := config.GetConfig(commands...)
+	if command != nil {
+		log.Logger = log.Logger.Level(zerolog.InfoLevel)
+		err := command.Run(&cfg, args)
+		if err != nil {
+			log.Error().Err(err).Msgf("Command '%s' failed", command.Name)
+			os.Exit(1)
+		}
+		return
+	}
+
+	collectors, err := collector.Select(cfg.Only, cfg.Skip)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid collector selection")
+		os.Exit(1)
+	}
+	for name := range cfg.CollectorTimeouts {
+		if _, ok := collector.Lookup(name); !ok {
+			log.Error().Msgf("Invalid collector timeout: unknown collector '%s'", name)
+			os.Exit(1)
+		}
+	}
+	for name := range cfg.Logs.Collectors {
+		if _, ok := collector.Lookup(name); !ok {
+			log.Error().Msgf("Invalid log window: unknown collector '%s'", name)
+			os.Exit(1)
+		}
+	}
+	err = cfg.Logs.Validate()
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid log window")
+		os.Exit(1)
+	}
+	if cfg.ThreadDumps.Count < 1 || cfg.ThreadDumps.Interval < 0 {
+		log.Error().Msgf("--thread-dumps must be at least 1 and --thread-dump-interval must not be negative")
+		os.Exit(1)
+	}
+	if cfg.Kubernetes.QPS <= 0 || cfg.Kubernetes.Burst < 1 || cfg.Kubernetes.RequestTimeout < 0 {
+		// a burst of 0 fails every request and a QPS of 0 blocks all requests after the first burst
+		log.Error().Msgf("--kube-api-qps must be positive, --kube-api-burst at least 1 and --kube-api-timeout must not be negative")
+		os.Exit(1)
+	}
+	if cfg.Stream && (cfg.Anonymize || cfg.MaxBundleSize > 0) {
+		// both rewrite files after the collection, which is impossible once they are in the archive
+		log.Error().Msgf("--stream can't be combined with --anonymize or --max-bundle-size")
+		os.Exit(1)
+	}
+	err = output.ValidateUpload(cfg.Upload)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid upload configuration")
+		os.Exit(1)
+	}
+	if cfg.Upload.Url != "" && cfg.OutputPath == output.StdoutPath {
+		log.Error().Msgf("--upload-url needs an archive file and can't be combined with -o -")
+		os.Exit(1)
+	}
+	if cfg.SplitSize > 0 && (cfg.Upload.Url != "" || cfg.OutputPath == output.StdoutPath) {
+		log.Error().Msgf("--split-size writes chunk files and can't be combined with -o - or --upload-url")
+		os.Exit(1)
+	}
+
+	scheduler.Configure(map[scheduler.Resource]int{
+		scheduler.Api:     parallelism(cfg.Parallelism.Api, cfg.Parallelism.Default),
+		scheduler.Process: parallelism(cfg.Parallelism.Processes, cfg.Parallelism.Default),
+		scheduler.Http:    parallelism(cfg.Parallelism.Http, cfg.Parallelism.Default),
+	})
+
+	err = output.ConfigureRedaction(cfg.Redaction)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid redaction configuration")
+		os.Exit(1)
+	}
+	err = output.ConfigureEncryption(cfg.EncryptTo)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid encryption configuration")
+		os.Exit(1)
+	}
+	err = versions.ConfigureCompatibility(cfg.CompatFile)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid compatibility rules")
+		os.Exit(1)
+	}
+
+	if cfg.DryRun {
+		// nothing is written, paths in the plan are relative to the bundle
+		cfg.OutputPath = ""
+		log.Logger = log.Logger.Level(zerolog.InfoLevel)
+		ctx, cancel := runContext(&cfg)
+		defer cancel()
+		err = debugrun.Plan(ctx, &cfg, collectors, os.Stdout)
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed to write the collection plan")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.OutputPath == output.StdoutPath {
+		// collect into the temp directory, stdout only receives the archive
+		output.WriteArchiveToStdout()
+		cfg.OutputPath = os.TempDir()
+	}
+	if cfg.Anonymize {
+		enableAnonymization(&cfg)
+	}
+
 	output.AddOutputDirectory(&cfg)
-	addLoggingToFile(&cfg)
+	closeLogFile := addLoggingToFile(&cfg)
 
 	output.AddJsonOutput(output.AddJsonOutputOptions{
 		Config:     &cfg,
-		Content:    cfg,
+		Content:    withoutUploadCredentials(cfg),
 		OutputPath: []string{"debugging_config.yaml"},
 	})
-	debugrun.GatherInformation(&cfg)
-	output.ZipOutputDirectory(&cfg)
+	ctx, cancel := runContext(&cfg)
+	if !cfg.NoPreflight {
+		collectors = runPreflight(ctx, &cfg, collectors)
+	}
+	debugrun.GatherInformation(ctx, &cfg, collectors)
+	cancel()
+	err = output.AnonymizeOutputDirectory(&cfg)
+	if err != nil {
+		// never archive clear text the user asked to hide
+		log.Error().Err(err).Msgf("Failed to anonymize the collected information, no archive is created")
+		if !cfg.NoCleanup {
+			os.RemoveAll(cfg.OutputPath)
+		}
+		os.Exit(1)
+	}
+	output.EnforceBundleSize(&cfg)
+	// log.txt must not change after the manifest recorded its checksum, when streaming closing adds it to the archive
+	closeLogFile()
+	output.AddManifest(&cfg)
+	archivePath, err := output.ArchiveOutputDirectory(&cfg)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to create the archive")
+		if !cfg.Stream {
+			log.Info().Msgf("The collected information is kept at %s", cfg.OutputPath)
+		}
+		os.Exit(1)
+	}
 
-	if !cfg.NoCleanup {
-		err := os.RemoveAll(cfg.OutputPath)
+	if !cfg.NoCleanup && !cfg.Stream {
+		err = os.RemoveAll(cfg.OutputPath)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to remove output directory '%s' after completion", cfg.OutputPath)
 		}
 	}
+
+	if cfg.Upload.Url != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err = output.UploadArchive(ctx, &cfg, archivePath)
+		stop()
+		if err != nil {
+			log.Info().Msgf("The archive is kept at %s, please upload it manually", archivePath)
+			os.Exit(1)
+		}
+	}
+}
+
+// withoutUploadCredentials returns a copy of cfg for the bundle without the signature of a presigned upload URL and
+// the values of upload headers
+func withoutUploadCredentials(cfg config.Config) config.Config {
+	cfg.Upload.Url = output.RedactUrl(cfg.Upload.Url)
+	headers := make([]string, len(cfg.Upload.Headers))
+	for i, header := range cfg.Upload.Headers {
+		name, _, _ := strings.Cut(header, ":")
+		headers[i] = name + ": " + output.RedactedValue
+	}
+	cfg.Upload.Headers = headers
+	return cfg
+}
+
+// runPreflight reports missing permissions and disables the collectors lacking required ones. The run continues
+// with all collectors if the permissions can't be reviewed.
+func runPreflight(ctx context.Context, cfg *config.Config, collectors []collector.Collector) []collector.Collector {
+	result, err := preflight.Run(ctx, cfg, collectors)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to check permissions, continuing with all collectors")
+		return collectors
+	}
+	output.AddJsonOutput(output.AddJsonOutputOptions{
+		Config:     cfg,
+		Content:    result,
+		OutputPath: []string{"preflight.json"},
+	})
+	if len(result.Gaps()) > 0 {
+		fmt.Fprintln(os.Stderr)
+		result.WriteReport(os.Stderr)
+		fmt.Fprintln(os.Stderr)
+	} else {
+		log.Debug().Msgf("Preflight: all permissions are granted")
+	}
+	return result.Apply(collectors)
+}
+
+// enableAnonymization registers the cluster topology to be replaced by tokens before anything gets collected
+func enableAnonymization(cfg *config.Config) {
+	mappingPath := cfg.AnonymizeMapping
+	if mappingPath == "" {
+		mappingPath = filepath.Join(cfg.OutputPath, output.AnonymizationMappingFileName)
+	}
+	err := output.EnableAnonymization(mappingPath)
+	if err == nil {
+		err = k8s.AddSensitiveValues(context.Background(), cfg)
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to prepare anonymization")
+		os.Exit(1)
+	}
+}
+
+// runContext is cancelled on SIGINT/SIGTERM or when the run timeout expires. A second signal terminates immediately.
+func runContext(cfg *config.Config) (context.Context, context.CancelFunc) {
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	cancel := func() { cancelCause(context.Canceled) }
+	if timeout := cfg.Timeout.Duration(); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("run timeout of %s exceeded", timeout))
+		cancel = func() {
+			cancelTimeout()
+			cancelCause(context.Canceled)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Warn().Msgf("Received %s, finishing the bundle with the information collected so far. Repeat to abort immediately.", sig)
+			cancelCause(fmt.Errorf("received signal %s", sig))
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
+
+func parallelism(value int, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
 }
 
-func addLoggingToFile(cfg *config.Config) *os.File {
-	file, err := os.OpenFile(
-		filepath.Join(cfg.OutputPath, "log.txt"),
-		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
-		0664,
-	)
+// addLoggingToFile logs to the console and log.txt. The returned function completes log.txt, later messages are only
+// logged to the console.
+func addLoggingToFile(cfg *config.Config) func() {
+	file, err := output.CreateFile(filepath.Join(cfg.OutputPath, "log.txt"))
 
+	console := &zerolog.FilteredLevelWriter{
+		Writer: zerolog.LevelWriterAdapter{Writer: zerolog.ConsoleWriter{Out: os.Stderr}},
+		Level:  zerolog.InfoLevel,
+	}
 	writers := []io.Writer{
-		&zerolog.FilteredLevelWriter{
-			Writer: zerolog.LevelWriterAdapter{Writer: zerolog.ConsoleWriter{Out: os.Stderr}},
-			Level:  zerolog.InfoLevel,
-		},
+		console,
 		&zerolog.FilteredLevelWriter{
 			Writer: zerolog.LevelWriterAdapter{Writer: file},
 			Level:  zerolog.DebugLevel,
@@ -61,5 +296,8 @@ func addLoggingToFile(cfg *config.Config) *os.File {
 	if err != nil {
 		panic(err)
 	}
-	return file
+	return func() {
+		log.Logger = zerolog.New(console).Level(zerolog.DebugLevel).With().Timestamp().Logger()
+		file.Close()
+	}
 }
@@ -0,0 +1,366 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	AnonymizationMappingFileName = "steadybit-debug-anonymization.json"
+
+	AnonymizeNode      = "node"
+	AnonymizeIp        = "ip"
+	AnonymizeNamespace = "ns"
+	AnonymizeDomain    = "domain"
+	AnonymizeHost      = "host"
+)
+
+// notSensitive are values that are the same in every cluster and would only make the output harder to read
+var notSensitive = map[string]bool{
+	"default":         true,
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+	"localhost":       true,
+	"127.0.0.1":       true,
+	"0.0.0.0":         true,
+	"::1":             true,
+	"::":              true,
+}
+
+var clusterDomainPattern = regexp.MustCompile(`\.svc\.((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)*[a-z0-9](?:[a-z0-9-]*[a-z0-9])?)`)
+
+type anonymizationMapping struct {
+	// Key makes tokens unguessable, it is reused so that tokens are stable across runs
+	Key    string               `json:"key"`
+	Tokens []anonymizationToken `json:"tokens"`
+}
+
+type anonymizationToken struct {
+	Token    string `json:"token"`
+	Category string `json:"category"`
+	Value    string `json:"value"`
+}
+
+var anonymization = struct {
+	mu          sync.Mutex
+	enabled     bool
+	mappingPath string
+	key         []byte
+	tokens      map[string]anonymizationToken
+	// values are the keys of tokens, longest first, matched by pattern
+	values  []string
+	pattern *regexp.Regexp
+}{tokens: map[string]anonymizationToken{}}
+
+// EnableAnonymization loads the key and tokens of previous runs from mappingPath, so that the same value is always
+// replaced by the same token
+func EnableAnonymization(mappingPath string) error {
+	anonymization.mu.Lock()
+	defer anonymization.mu.Unlock()
+	anonymization.enabled = true
+	anonymization.mappingPath = mappingPath
+
+	content, err := os.ReadFile(mappingPath)
+	if os.IsNotExist(err) {
+		anonymization.key = make([]byte, 32)
+		_, err = rand.Read(anonymization.key)
+		return err
+	} else if err != nil {
+		return fmt.Errorf("failed to read anonymization mapping '%s': %w", mappingPath, err)
+	}
+
+	var mapping anonymizationMapping
+	if err = json.Unmarshal(content, &mapping); err != nil {
+		return fmt.Errorf("failed to parse anonymization mapping '%s': %w", mappingPath, err)
+	}
+	anonymization.key, err = hex.DecodeString(mapping.Key)
+	if err != nil || len(anonymization.key) == 0 {
+		return fmt.Errorf("invalid key in anonymization mapping '%s'", mappingPath)
+	}
+	for _, token := range mapping.Tokens {
+		anonymization.tokens[token.Value] = token
+	}
+	return nil
+}
+
+// Anonymizing returns true if sensitive values are replaced by tokens
+func Anonymizing() bool {
+	anonymization.mu.Lock()
+	defer anonymization.mu.Unlock()
+	return anonymization.enabled
+}
+
+// AddSensitiveValue registers a value to be replaced by a token of the given category in all artifacts
+func AddSensitiveValue(category string, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" || notSensitive[value] {
+		return
+	}
+	anonymization.mu.Lock()
+	defer anonymization.mu.Unlock()
+	if !anonymization.enabled {
+		return
+	}
+	if _, ok := anonymization.tokens[value]; ok {
+		return
+	}
+	mac := hmac.New(sha256.New, anonymization.key)
+	mac.Write([]byte(category + ":" + value))
+	anonymization.tokens[value] = anonymizationToken{
+		Token:    fmt.Sprintf("%s-%s", category, hex.EncodeToString(mac.Sum(nil))[:8]),
+		Category: category,
+		Value:    value,
+	}
+	anonymization.pattern = nil
+}
+
+// anonymizeText replaces all registered values that are not part of a longer name or address. Namespaces are only
+// replaced where they are used as namespace, as their names often are common words or also name workloads.
+func anonymizeText(s string) string {
+	anonymization.mu.Lock()
+	if !anonymization.enabled || len(anonymization.tokens) == 0 {
+		anonymization.mu.Unlock()
+		return s
+	}
+	if anonymization.pattern == nil {
+		values := make([]string, 0, len(anonymization.tokens))
+		for value := range anonymization.tokens {
+			values = append(values, value)
+		}
+		// longest first, so that a node name wins over the IP it contains
+		sort.Slice(values, func(i, j int) bool {
+			if len(values[i]) != len(values[j]) {
+				return len(values[i]) > len(values[j])
+			}
+			return values[i] < values[j]
+		})
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = regexp.QuoteMeta(value)
+		}
+		anonymization.values = values
+		anonymization.pattern = regexp.MustCompile(strings.Join(quoted, "|"))
+	}
+	pattern, values, tokens := anonymization.pattern, anonymization.values, anonymization.tokens
+	anonymization.mu.Unlock()
+
+	var b strings.Builder
+	last := 0
+	for start := 0; start < len(s); {
+		m := pattern.FindStringIndex(s[start:])
+		if m == nil {
+			break
+		}
+		from, to := start+m[0], start+m[1]
+		value := s[from:to]
+		if !replaceable(s, from, value, tokens[value].Category) {
+			// a shorter value may match at the same position, e.g. the short name of a node whose FQDN is skipped
+			value = ""
+			for _, candidate := range values[sort.Search(len(values), func(i int) bool { return len(values[i]) < to-from }):] {
+				if strings.HasPrefix(s[from:], candidate) && replaceable(s, from, candidate, tokens[candidate].Category) {
+					value = candidate
+					break
+				}
+			}
+		}
+		if value == "" {
+			start = from + 1
+			continue
+		}
+		b.WriteString(s[last:from])
+		b.WriteString(tokens[value].Token)
+		last = from + len(value)
+		start = last
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// namespaceKeyPattern matches the text before a namespace used as value, e.g. 'namespace: ', '"namespace":"' or '-n '
+var namespaceKeyPattern = regexp.MustCompile(`(?i)(namespace"?\s*[:=]\s*"?|(^|\s)(-n|--namespace)[\s=]+|namespaces/)$`)
+
+// replaceable reports whether the value at from is a whole name, and for namespaces whether it is in a structured
+// position: a namespace key, 'namespace/name', a DNS name like 'service.namespace.svc' or the whole text, e.g. a file name
+func replaceable(s string, from int, value string, category string) bool {
+	to := from + len(value)
+	if (from > 0 && isNameChar(s[from-1])) || (to < len(s) && isNameChar(s[to])) {
+		return false
+	}
+	if category != AnonymizeNamespace || (from == 0 && to == len(s)) {
+		return true
+	}
+	rest := s[to:]
+	if strings.HasPrefix(rest, ".svc") || (len(rest) > 1 && rest[0] == '/' && isNameChar(rest[1])) {
+		return true
+	}
+	return namespaceKeyPattern.MatchString(s[max(0, from-32):from])
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// AnonymizeOutputDirectory replaces the registered values and detected cluster domains in the content and names of
+// all files of the output directory and writes the mapping file. It must be called after all collectors finished and
+// before the manifest is written.
+func AnonymizeOutputDirectory(cfg *config.Config) error {
+	if !Anonymizing() {
+		return nil
+	}
+	log.Info().Msgf("Anonymizing collected information")
+
+	var files, dirs []string
+	err := filepath.WalkDir(cfg.OutputPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == cfg.OutputPath {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+		} else {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list output directory: %w", err)
+	}
+
+	for _, file := range files {
+		if err = forEachLine(file, func(line string) {
+			for _, m := range clusterDomainPattern.FindAllStringSubmatch(line, -1) {
+				AddSensitiveValue(AnonymizeDomain, m[1])
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		if err = anonymizeFile(file); err != nil {
+			return err
+		}
+	}
+
+	// deepest first, so that renaming a directory doesn't invalidate the paths of its children
+	paths := append(files, dirs...)
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Count(paths[i], string(filepath.Separator)) > strings.Count(paths[j], string(filepath.Separator))
+	})
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if anonymized := anonymizeText(base); anonymized != base {
+			if err = os.Rename(path, filepath.Join(filepath.Dir(path), anonymized)); err != nil {
+				return fmt.Errorf("failed to rename '%s': %w", path, err)
+			}
+		}
+	}
+
+	anonymizeArtifacts(func(path string) string {
+		relative, err := filepath.Rel(cfg.OutputPath, path)
+		if err != nil {
+			return path
+		}
+		return filepath.Join(cfg.OutputPath, anonymizeText(relative))
+	}, anonymizeText)
+
+	return writeAnonymizationMapping()
+}
+
+func forEachLine(path string, fn func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			fn(line)
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read '%s': %w", path, err)
+		}
+	}
+}
+
+// anonymizeFile rewrites the file in place. Writers still holding the file, like the one of log.txt, continue at the
+// new end, as CreateFile opens files for appending.
+func anonymizeFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	if isBinary(content) {
+		log.Debug().Msgf("Not anonymizing binary file '%s'", path)
+		return nil
+	}
+	var b bytes.Buffer
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		b.WriteString(anonymizeText(line))
+	}
+	if bytes.Equal(b.Bytes(), content) {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize '%s': %w", path, err)
+	}
+	defer file.Close()
+	_, err = file.Write(b.Bytes())
+	return err
+}
+
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+func writeAnonymizationMapping() error {
+	anonymization.mu.Lock()
+	mapping := anonymizationMapping{
+		Key:    hex.EncodeToString(anonymization.key),
+		Tokens: make([]anonymizationToken, 0, len(anonymization.tokens)),
+	}
+	for _, token := range anonymization.tokens {
+		mapping.Tokens = append(mapping.Tokens, token)
+	}
+	path := anonymization.mappingPath
+	anonymization.mu.Unlock()
+
+	sort.Slice(mapping.Tokens, func(i, j int) bool {
+		return mapping.Tokens[i].Token < mapping.Tokens[j].Token
+	})
+	content, err := json.MarshalIndent(mapping, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write anonymization mapping '%s': %w", path, err)
+	}
+	log.Info().Msgf("Anonymization mapping written to %s. It is not part of the archive, keep it to translate tokens back.", path)
+	return nil
+}
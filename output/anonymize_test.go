@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeText(t *testing.T) {
+	if err := EnableAnonymization(filepath.Join(t.TempDir(), "mapping.json")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		anonymization.mu.Lock()
+		defer anonymization.mu.Unlock()
+		anonymization.enabled = false
+		anonymization.tokens = map[string]anonymizationToken{}
+		anonymization.values = nil
+		anonymization.pattern = nil
+	}()
+	AddSensitiveValue(AnonymizeNamespace, "steadybit-agent")
+	AddSensitiveValue(AnonymizeNamespace, "shop")
+	AddSensitiveValue(AnonymizeNode, "worker-1.example.com")
+	AddSensitiveValue(AnonymizeNode, "worker-1")
+	AddSensitiveValue(AnonymizeIp, "10.0.0.1")
+	token := func(value string) string {
+		return anonymization.tokens[value].Token
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "yaml namespace", text: "  namespace: steadybit-agent\n", want: "  namespace: " + token("steadybit-agent") + "\n"},
+		{name: "json namespace", text: `{"namespace":"shop","name":"shop"}`, want: `{"namespace":"` + token("shop") + `","name":"shop"}`},
+		{name: "describe namespace", text: "Namespace:        steadybit-agent", want: "Namespace:        " + token("steadybit-agent")},
+		{name: "kubectl argument", text: "kubectl logs -n shop checkout-7d4b9", want: "kubectl logs -n " + token("shop") + " checkout-7d4b9"},
+		{name: "namespace and name", text: "Pod shop/checkout-7d4b9 is not ready", want: "Pod " + token("shop") + "/checkout-7d4b9 is not ready"},
+		{name: "service DNS name", text: "http://platform.steadybit-agent.svc.cluster.local:80", want: "http://platform." + token("steadybit-agent") + ".svc.cluster.local:80"},
+		{name: "file name", text: "steadybit-agent", want: token("steadybit-agent")},
+		{name: "namespace as a word", text: "Connecting to the shop backend", want: "Connecting to the shop backend"},
+		{name: "namespace in a longer name", text: "app: steadybit-agent-0", want: "app: steadybit-agent-0"},
+		{name: "node FQDN", text: "node worker-1.example.com is ready", want: "node " + token("worker-1.example.com") + " is ready"},
+		{name: "short node name if the FQDN is part of a longer name", text: "worker-1.example.community", want: token("worker-1") + ".example.community"},
+		{name: "ip within a longer address", text: "10.0.0.12 and 10.0.0.1", want: "10.0.0.12 and " + token("10.0.0.1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := anonymizeText(tt.text); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"steadybit-agent", "shop", "worker-1", "10.0.0.1"} {
+		if !strings.HasPrefix(token(value), anonymization.tokens[value].Category+"-") {
+			t.Errorf("got token %q for %s", token(value), value)
+		}
+	}
+}
+
+func TestAnonymizeFileWhileOpen(t *testing.T) {
+	if err := EnableAnonymization(filepath.Join(t.TempDir(), "mapping.json")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		anonymization.mu.Lock()
+		defer anonymization.mu.Unlock()
+		anonymization.enabled = false
+		anonymization.tokens = map[string]anonymizationToken{}
+		anonymization.values = nil
+		anonymization.pattern = nil
+	}()
+	AddSensitiveValue(AnonymizeNode, "ip-10-0-128-17.eu-central-1.compute.internal")
+	token := anonymization.tokens["ip-10-0-128-17.eu-central-1.compute.internal"].Token
+
+	path := filepath.Join(t.TempDir(), "log.txt")
+	file, err := CreateFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.Write([]byte("node ip-10-0-128-17.eu-central-1.compute.internal is ready\n"))
+	if err := anonymizeFile(path); err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("Anonymization mapping written\n"))
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "node " + token + " is ready\nAnonymization mapping written\n"; string(content) != want {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
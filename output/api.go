@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"os"
+	"time"
+)
+
+type AddApiOutputOptions struct {
+	Config                 *config.Config
+	Request                string
+	OutputPath             string
+	Executions             int
+	DelayBetweenExecutions *time.Duration
+	ExecutionContext       string
+	LogError               bool
+	// Truncatable outputs, e.g. logs, may be cut to their most recent lines to fit the maximum bundle size
+	Truncatable bool
+	// Fn writes the response of the API request. Secrets are masked line by line as it is written, the output file
+	// gets the response once Fn returned.
+	Fn func(ctx context.Context, w io.Writer) error
+}
+
+// AddApiOutput opts.OutputPath must include a %d to replace the execution number when opts.Executions > 1
+func AddApiOutput(ctx context.Context, opts AddApiOutputOptions) {
+	if opts.Executions < 1 {
+		opts.Executions = 1
+	}
+
+	if opts.DelayBetweenExecutions == nil {
+		delay := time.Second
+		opts.DelayBetweenExecutions = &delay
+	}
+
+	if DryRun() {
+		AddPlannedStep(ctx, PlannedStep{
+			Target:     opts.ExecutionContext,
+			Kind:       ArtifactKindApi,
+			Command:    opts.Request,
+			OutputPath: opts.OutputPath,
+			Executions: opts.Executions,
+		})
+		return
+	}
+
+	for i := 0; i < opts.Executions; i++ {
+		filePath := opts.OutputPath
+
+		if opts.Executions > 1 {
+			filePath = fmt.Sprintf(filePath, i)
+		}
+
+		if skipCancelled(ctx, Artifact{Path: filePath, Target: opts.ExecutionContext, Kind: ArtifactKindApi, Command: opts.Request}) {
+			return
+		}
+		addApiOutputWithoutLoop(ctx, opts, filePath)
+
+		if i < opts.Executions-1 && !sleep(ctx, *opts.DelayBetweenExecutions) {
+			return
+		}
+	}
+}
+
+func addApiOutputWithoutLoop(ctx context.Context, opts AddApiOutputOptions, outputPath string) {
+	start := time.Now()
+
+	file, err := CreateFile(outputPath)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to create output file '%s'", outputPath)
+		recordArtifact(ctx, Artifact{Path: outputPath, Target: opts.ExecutionContext, Kind: ArtifactKindApi, Command: opts.Request}, start, err)
+		return
+	}
+	defer file.Close()
+
+	// the response is kept in a temporary file until the request completed, so that an error is written right after
+	// the header like for commands, without holding big responses like logs in memory
+	body, err := os.CreateTemp("", "steadybit-debug-api-*")
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to create temporary file for '%s'", outputPath)
+		recordArtifact(ctx, Artifact{Path: outputPath, Target: opts.ExecutionContext, Kind: ArtifactKindApi, Command: opts.Request}, start, err)
+		return
+	}
+	defer func() {
+		body.Close()
+		os.Remove(body.Name())
+	}()
+
+	log.Debug().Msgf("Executing: %s", opts.Request)
+	w := newRedactingWriter(body, outputPath)
+	err = opts.Fn(ctx, w)
+	w.Flush()
+
+	fmt.Fprintf(file, "# Executed request: %s", opts.Request)
+	fmt.Fprintf(file, "\n# Started at: %s", start.Format(time.RFC3339))
+	if err != nil {
+		fmt.Fprintf(file, "\n# Resulted in error: %s", err)
+		if opts.LogError {
+			log.Error().Str("context", opts.ExecutionContext).Str("request", opts.Request).Msgf("Error executing request")
+		} else {
+			log.Debug().Str("context", opts.ExecutionContext).Str("request", opts.Request).Msgf("Error executing request")
+		}
+	}
+	fmt.Fprint(file, "\n\n")
+	_, copyErr := body.Seek(0, io.SeekStart)
+	if copyErr == nil {
+		_, copyErr = io.Copy(file, body)
+	}
+	if copyErr != nil {
+		log.Error().Err(copyErr).Msgf("Failed to write the response to '%s'", outputPath)
+	}
+
+	incomplete := CancellationReason(ctx)
+	if incomplete != "" {
+		fmt.Fprintf(file, "\n\n# Incomplete: %s", incomplete)
+	}
+
+	totalTime := time.Now().Sub(start)
+	fmt.Fprintf(file, "\n\n# Total execution time: %d millis", totalTime.Milliseconds())
+	recordArtifact(ctx, Artifact{
+		Path:        outputPath,
+		Target:      opts.ExecutionContext,
+		Kind:        ArtifactKindApi,
+		Command:     opts.Request,
+		Incomplete:  incomplete,
+		Truncatable: opts.Truncatable,
+	}, start, err)
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestAddApiOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "success",
+			want: `^# Executed request: GET /api/health\n# Started at: \S+\n\nstatus: UP\npassword: <redacted>\n\n# Total execution time: \d+ millis$`,
+		},
+		{
+			name: "error right after the header",
+			err:  errors.New("connection reset by peer"),
+			want: `^# Executed request: GET /api/health\n# Started at: \S+\n# Resulted in error: connection reset by peer\n\nstatus: UP\npassword: <redacted>\n\n# Total execution time: \d+ millis$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "health.txt")
+			AddApiOutput(context.Background(), AddApiOutputOptions{
+				Request:    "GET /api/health",
+				OutputPath: path,
+				Fn: func(ctx context.Context, w io.Writer) error {
+					io.WriteString(w, "status: UP\npassword: secret")
+					return tt.err
+				},
+			})
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !regexp.MustCompile(tt.want).Match(content) {
+				t.Errorf("got\n%s", content)
+			}
+		})
+	}
+}
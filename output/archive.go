@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Archive formats, see config.Config.ArchiveFormat
+const (
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// StdoutPath as output path writes the archive to stdout, see WriteArchiveToStdout
+const StdoutPath = "-"
+
+const (
+	archiveFileMode = 0644
+	archiveDirMode  = 0755
+)
+
+// archiveWriter writes the entries of an archive. All entries carry the same modification time and normalized modes,
+// so that the same files always result in the same archive.
+type archiveWriter interface {
+	writeDir(name string) error
+	// writeFile copies exactly size bytes from r, files that grow while being archived are cut
+	writeFile(name string, size int64, r io.Reader) error
+	Close() error
+}
+
+func newArchiveWriter(format string, w io.Writer, modTime time.Time) (archiveWriter, error) {
+	switch format {
+	case ArchiveFormatTarGz, "":
+		gz := gzip.NewWriter(w)
+		gz.ModTime = modTime
+		return &tarGzWriter{gz: gz, tw: tar.NewWriter(gz), modTime: modTime}, nil
+	case ArchiveFormatZip:
+		return &zipWriter{zw: zip.NewWriter(w), modTime: modTime}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive format '%s'", format)
+	}
+}
+
+type tarGzWriter struct {
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	modTime time.Time
+}
+
+func (w *tarGzWriter) writeDir(name string) error {
+	return w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: archiveDirMode, ModTime: w.modTime})
+}
+
+func (w *tarGzWriter) writeFile(name string, size int64, r io.Reader) error {
+	err := w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: archiveFileMode, ModTime: w.modTime})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(w.tw, r, size)
+	return err
+}
+
+func (w *tarGzWriter) Close() error {
+	return errors.Join(w.tw.Close(), w.gz.Close())
+}
+
+type zipWriter struct {
+	zw      *zip.Writer
+	modTime time.Time
+}
+
+func (w *zipWriter) writeDir(name string) error {
+	header := &zip.FileHeader{Name: name + "/", Modified: w.modTime}
+	header.SetMode(fs.ModeDir | archiveDirMode)
+	_, err := w.zw.CreateHeader(header)
+	return err
+}
+
+func (w *zipWriter) writeFile(name string, size int64, r io.Reader) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: w.modTime}
+	header.SetMode(archiveFileMode)
+	fw, err := w.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(fw, r, size)
+	return err
+}
+
+func (w *zipWriter) Close() error {
+	return w.zw.Close()
+}
+
+// archiveFile is an archive written to disk, stdout or split into chunks, encrypted if recipients are configured, so
+// that an encrypted archive never exists in plain text
+type archiveFile struct {
+	archiveWriter
+	path      string
+	target    archiveTarget
+	encrypted io.WriteCloser
+}
+
+// archiveTarget receives the bytes of the archive
+type archiveTarget interface {
+	io.WriteCloser
+	// remove deletes everything written so far, if possible
+	remove()
+}
+
+type fileTarget struct {
+	*os.File
+}
+
+func (t fileTarget) remove() {
+	t.File.Close()
+	os.Remove(t.Name())
+}
+
+// stdoutTarget is never closed, and a partial archive on stdout can't be taken back
+type stdoutTarget struct {
+	io.Writer
+}
+
+func (stdoutTarget) Close() error {
+	return nil
+}
+
+func (stdoutTarget) remove() {}
+
+var archiveToStdout bool
+
+// WriteArchiveToStdout writes the archive to stdout instead of a file next to the output directory. Nothing else may be
+// written to stdout afterwards.
+func WriteArchiveToStdout() {
+	archiveToStdout = true
+}
+
+// ArchivePath returns the path of the archive for the output directory, e.g. steadybit-debug-123.tar.gz, or
+// StdoutPath
+func ArchivePath(cfg *config.Config) string {
+	if archiveToStdout {
+		return StdoutPath
+	}
+	format := cfg.ArchiveFormat
+	if format == "" {
+		format = ArchiveFormatTarGz
+	}
+	path := fmt.Sprintf("%s.%s", cfg.OutputPath, format)
+	if len(encryptionRecipients()) > 0 {
+		path += EncryptedFileExtension
+	}
+	return path
+}
+
+func createArchive(cfg *config.Config) (*archiveFile, error) {
+	a := &archiveFile{path: ArchivePath(cfg)}
+	switch {
+	case archiveToStdout:
+		a.target = stdoutTarget{Writer: os.Stdout}
+	case cfg.SplitSize > 0:
+		a.target = newSplitTarget(a.path, int64(cfg.SplitSize))
+	default:
+		file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return nil, err
+		}
+		a.target = fileTarget{File: file}
+	}
+	var err error
+	a.encrypted, err = encryptingWriter(a.target)
+	if err == nil {
+		a.archiveWriter, err = newArchiveWriter(cfg.ArchiveFormat, a.encrypted, bundleTime())
+	}
+	if err != nil {
+		a.abort()
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *archiveFile) Close() error {
+	err := a.archiveWriter.Close()
+	if err == nil {
+		err = a.encrypted.Close()
+	}
+	if err == nil {
+		err = a.target.Close()
+	}
+	return err
+}
+
+// abort removes the partially written archive
+func (a *archiveFile) abort() {
+	a.target.remove()
+}
+
+// addDirectory writes all files below root in lexical order, named relative to the parent of root
+func addDirectory(archive archiveWriter, root string) error {
+	parent := filepath.Dir(root)
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(parent, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if d.IsDir() {
+			return archive.writeDir(name)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		return archive.writeFile(name, info.Size(), file)
+	})
+}
@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// truncationNoteReserve is reserved for the '# Truncated: ...' line when calculating how much of a file is kept
+const truncationNoteReserve = 128
+
+// EnforceBundleSize shortens truncatable artifacts, i.e. logs, until the output directory fits cfg.MaxBundleSize. The
+// biggest logs are shortened first and all of them down to the same size, keeping their most recent lines. The budget
+// applies to the uncompressed files, the archive is smaller.
+func EnforceBundleSize(cfg *config.Config) {
+	budget := int64(cfg.MaxBundleSize)
+	if budget <= 0 {
+		return
+	}
+
+	truncatable := map[string]bool{}
+	for _, artifact := range Artifacts() {
+		if artifact.Truncatable {
+			truncatable[artifact.Path] = true
+		}
+	}
+
+	type candidate struct {
+		path string
+		size int64
+	}
+	var candidates []candidate
+	total, err := directorySize(cfg.OutputPath, func(path string, size int64) {
+		if truncatable[path] {
+			candidates = append(candidates, candidate{path: path, size: size})
+		}
+	})
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to determine the size of output directory '%s'", cfg.OutputPath)
+		return
+	}
+	excess := total - budget
+	if excess <= 0 {
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].size > candidates[j].size
+	})
+	sizes := make([]int64, len(candidates))
+	for i, c := range candidates {
+		sizes[i] = c.size
+	}
+	limit := truncationLimit(sizes, excess)
+	log.Info().Msgf("Collected files exceed the maximum bundle size of %s by %s, shortening logs to %s", cfg.MaxBundleSize, config.ByteSize(excess), config.ByteSize(limit))
+
+	for _, c := range candidates {
+		if c.size <= limit {
+			break
+		}
+		removed, err := truncateFile(c.path, limit, cfg.MaxBundleSize)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to shorten '%s'", c.path)
+			continue
+		}
+		markTruncated(c.path, removed)
+	}
+
+	total, err = directorySize(cfg.OutputPath, func(string, int64) {})
+	if err == nil && total > budget {
+		log.Warn().Msgf("Collected files still exceed the maximum bundle size of %s after shortening all logs (%s)", cfg.MaxBundleSize, config.ByteSize(total))
+	}
+}
+
+func directorySize(root string, fn func(path string, size int64)) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		fn(path, info.Size())
+		return nil
+	})
+	return total, err
+}
+
+// truncationLimit returns the size all files bigger than it are cut to, so that at least excess bytes are removed.
+// sizes must be sorted in descending order. The limit is 0 if cutting all files isn't enough.
+func truncationLimit(sizes []int64, excess int64) int64 {
+	var sum int64
+	for i, size := range sizes {
+		sum += size
+		next := int64(0)
+		if i+1 < len(sizes) {
+			next = sizes[i+1]
+		}
+		// cutting the i+1 biggest files to limit removes sum - (i+1)*limit bytes
+		limit := (sum - excess) / int64(i+1)
+		if limit >= next {
+			return max(limit, 0)
+		}
+	}
+	return 0
+}
+
+// truncateFile keeps the header of the file and its last lines, so that the result has at most about limit bytes. It
+// returns the number of removed bytes.
+func truncateFile(path string, limit int64, budget config.ByteSize) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// the header consists of the '# ...' lines up to the first empty line
+	reader := bufio.NewReader(file)
+	var header []string
+	var headerSize int64
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || line == "\n" || line[0] != '#' {
+			header = nil
+			headerSize = 0
+			break
+		}
+		header = append(header, line)
+		headerSize += int64(len(line))
+		if next, _ := reader.Peek(1); len(next) == 1 && next[0] == '\n' {
+			headerSize++
+			break
+		}
+	}
+
+	bodySize := info.Size() - headerSize
+	keep := max(limit-headerSize-truncationNoteReserve, 0)
+	if keep >= bodySize {
+		return 0, nil
+	}
+	// start at the first complete line
+	offset := headerSize + bodySize - keep
+	if _, err = file.Seek(offset-1, io.SeekStart); err != nil {
+		return 0, err
+	}
+	reader = bufio.NewReader(file)
+	if previous, _ := reader.ReadByte(); previous != '\n' {
+		skipped, _ := reader.ReadString('\n')
+		offset += int64(len(skipped))
+	}
+	kept := info.Size() - offset
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if err = tmp.Chmod(info.Mode()); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+
+	w := bufio.NewWriter(tmp)
+	for _, line := range header {
+		w.WriteString(line)
+	}
+	fmt.Fprintf(w, "# Truncated: kept the last %d of %d bytes to fit the maximum bundle size of %s\n", kept, bodySize, budget)
+	if len(header) > 0 {
+		w.WriteString("\n")
+	}
+	_, err = io.Copy(w, reader)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return bodySize - kept, nil
+}
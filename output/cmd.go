@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/scheduler"
 	"io"
 	"os/exec"
 	"strings"
@@ -21,6 +22,10 @@ type AddCommandOutputOptions struct {
 	Stdin                  io.Reader
 	ExecutionContext       string
 	LogError               bool
+	// Resources are held in addition to a scheduler.Process slot while the command runs, e.g. scheduler.Http for curl
+	Resources []scheduler.Resource
+	// OnOutput is called with the raw output of each execution after it was written to outputPath
+	OnOutput func(outputPath string, out []byte)
 }
 
 // AddCommandOutput opts.OutputPath must include a %d to replace the execution number when opts.Executions > 1
@@ -34,6 +39,17 @@ func AddCommandOutput(ctx context.Context, opts AddCommandOutputOptions) {
 		opts.DelayBetweenExecutions = &delay
 	}
 
+	if DryRun() {
+		AddPlannedStep(ctx, PlannedStep{
+			Target:     opts.ExecutionContext,
+			Kind:       ArtifactKindCommand,
+			Command:    command(opts),
+			OutputPath: opts.OutputPath,
+			Executions: opts.Executions,
+		})
+		return
+	}
+
 	for i := 0; i < opts.Executions; i++ {
 		filePath := opts.OutputPath
 
@@ -41,16 +57,21 @@ func AddCommandOutput(ctx context.Context, opts AddCommandOutputOptions) {
 			filePath = fmt.Sprintf(filePath, i)
 		}
 
+		if skipCancelled(ctx, Artifact{Path: filePath, Target: opts.ExecutionContext, Kind: ArtifactKindCommand, Command: command(opts)}) {
+			return
+		}
 		addCommandOutputWithoutLoop(ctx, opts, filePath)
 
-		time.Sleep(*opts.DelayBetweenExecutions)
+		if i < opts.Executions-1 && !sleep(ctx, *opts.DelayBetweenExecutions) {
+			return
+		}
 	}
 }
 
 func addCommandOutputWithoutLoop(ctx context.Context, opts AddCommandOutputOptions, outputPath string) {
 	start := time.Now()
 
-	content := fmt.Sprintf("# Executed command: %s %s", opts.CommandName, strings.Join(opts.CommandArgs, " "))
+	content := fmt.Sprintf("# Executed command: %s", command(opts))
 	content = fmt.Sprintf("%s\n# Started at: %s", content, time.Now().Format(time.RFC3339))
 
 	cmd := exec.CommandContext(ctx, opts.CommandName, opts.CommandArgs...)
@@ -58,7 +79,12 @@ func addCommandOutputWithoutLoop(ctx context.Context, opts AddCommandOutputOptio
 	if opts.Stdin != nil {
 		cmd.Stdin = opts.Stdin
 	}
-	out, err := cmd.CombinedOutput()
+	var out []byte
+	err := doWithResources(ctx, append(opts.Resources, scheduler.Process), func() error {
+		var err error
+		out, err = cmd.CombinedOutput()
+		return err
+	})
 	if err != nil {
 		content = fmt.Sprintf("%s\n# Resulted in error: %s", content, err)
 		if opts.LogError {
@@ -69,9 +95,52 @@ func addCommandOutputWithoutLoop(ctx context.Context, opts AddCommandOutputOptio
 
 	}
 	content = fmt.Sprintf("%s\n\n%s", content, out)
+	incomplete := CancellationReason(ctx)
+	if incomplete != "" {
+		content = fmt.Sprintf("%s\n\n# Incomplete: %s", content, incomplete)
+	}
 
 	totalTime := time.Now().Sub(start)
 	content = fmt.Sprintf("%s\n\n# Total execution time: %d millis", content, totalTime.Milliseconds())
 
 	WriteToFile(outputPath, []byte(strings.TrimSpace(content)))
+	if opts.OnOutput != nil {
+		opts.OnOutput(outputPath, out)
+	}
+	if err != nil {
+		// the reason is usually found in the last line of the output, e.g. 'Error from server (Forbidden): ...'
+		if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); lines[len(lines)-1] != "" {
+			err = fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+		}
+	}
+	recordArtifact(ctx, Artifact{
+		Path:       outputPath,
+		Target:     opts.ExecutionContext,
+		Kind:       ArtifactKindCommand,
+		Command:    command(opts),
+		ExitCode:   exitCode(cmd),
+		Incomplete: incomplete,
+	}, start, err)
+}
+
+func command(opts AddCommandOutputOptions) string {
+	return fmt.Sprintf("%s %s", opts.CommandName, strings.Join(opts.CommandArgs, " "))
+}
+
+// exitCode returns nil if the command didn't start, and -1 if it got killed by a signal
+func exitCode(cmd *exec.Cmd) *int {
+	if cmd.ProcessState == nil {
+		return nil
+	}
+	code := cmd.ProcessState.ExitCode()
+	return &code
+}
+
+func doWithResources(ctx context.Context, resources []scheduler.Resource, fn func() error) error {
+	if len(resources) == 0 {
+		return fn()
+	}
+	return scheduler.Do(ctx, resources[0], func() error {
+		return doWithResources(ctx, resources[1:], fn)
+	})
 }
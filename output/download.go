@@ -5,9 +5,13 @@
 package output
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/scheduler"
+	"io"
 	"net/url"
 	"os/exec"
 	"strings"
@@ -19,40 +23,78 @@ type DownloadOptions struct {
 	Method     string
 	URL        url.URL
 	OutputPath string
+	// ExecutionContext identifies the pod the download is coming from
+	ExecutionContext string
 }
 
-func DownloadOutput(opts DownloadOptions) {
+func DownloadOutput(ctx context.Context, opts DownloadOptions) {
+	if DryRun() {
+		AddPlannedStep(ctx, PlannedStep{
+			Target:     opts.ExecutionContext,
+			Kind:       ArtifactKindDownload,
+			Command:    fmt.Sprintf("%s %s", opts.Method, opts.URL.String()),
+			OutputPath: opts.OutputPath,
+		})
+		return
+	}
+	if skipCancelled(ctx, Artifact{Path: opts.OutputPath, Target: opts.ExecutionContext, Kind: ArtifactKindDownload, Command: fmt.Sprintf("%s %s", opts.Method, opts.URL.String())}) {
+		return
+	}
 	start := time.Now()
 	outputPathLog := opts.OutputPath + ".log"
 
-	commandArgs := getCommandArgs(opts, false)
+	// when streaming, curl writes to stdout and the download is added to the archive
+	var target io.WriteCloser
+	if streaming() {
+		target, _ = CreateFile(opts.OutputPath)
+		defer target.Close()
+	}
+	commandArgs := getCommandArgs(opts, false, target != nil)
 
 	logContent := fmt.Sprintf("# Executed command: %s %s", "curl", strings.Join(commandArgs, " "))
 	logContent = fmt.Sprintf("%s\n# Started at: %s", logContent, time.Now().Format(time.RFC3339))
 
-	out, err := doCurl(commandArgs)
-	fmt.Println(string(out))
+	out, err := doCurl(ctx, commandArgs, target)
+	log.Debug().Msgf("Download of '%s' resulted in: %s", opts.OutputPath, strings.TrimSpace(string(out)))
 	if err != nil {
 		logContent = fmt.Sprintf("%s\n# Resulted in error: %s", logContent, err)
 	}
 	if strings.Contains(string(out), "Client sent an HTTP request to an HTTPS server") {
-		commandArgs := getCommandArgs(opts, true)
-		out, err = doCurl(commandArgs)
+		if entry, ok := target.(*streamEntry); ok {
+			entry.reset()
+		}
+		commandArgs := getCommandArgs(opts, true, target != nil)
+		out, err = doCurl(ctx, commandArgs, target)
 		if err != nil {
 			logContent = fmt.Sprintf("%s\n# Resulted in error: %s", logContent, err)
 		}
 	}
+	incomplete := CancellationReason(ctx)
+	if incomplete != "" {
+		logContent = fmt.Sprintf("%s\n# Incomplete: %s", logContent, incomplete)
+	}
 	totalTime := time.Now().Sub(start)
 	logContent = fmt.Sprintf("%s\n\n# Total execution time: %d millis", logContent, totalTime.Milliseconds())
 
 	WriteToFile(outputPathLog, []byte(strings.TrimSpace(logContent)))
+	recordArtifact(ctx, Artifact{
+		Path:       opts.OutputPath,
+		Target:     opts.ExecutionContext,
+		Kind:       ArtifactKindDownload,
+		Command:    fmt.Sprintf("%s %s", opts.Method, opts.URL.String()),
+		Incomplete: incomplete,
+	}, start, err)
 }
 
-func getCommandArgs(opts DownloadOptions, insecure bool) []string {
+func getCommandArgs(opts DownloadOptions, insecure bool, toStdout bool) []string {
+	outputPath := opts.OutputPath
+	if toStdout {
+		outputPath = "-"
+	}
 	commandArgs := []string{
 		"-X", opts.Method,
 		"-s", opts.URL.String(),
-		"--output", opts.OutputPath,
+		"--output", outputPath,
 	}
 	if opts.URL.Scheme == "https" || insecure {
 		commandArgs = append(commandArgs, "--insecure")
@@ -60,10 +102,24 @@ func getCommandArgs(opts DownloadOptions, insecure bool) []string {
 	return commandArgs
 }
 
-func doCurl(commandArgs []string) ([]byte, error) {
+// doCurl returns the combined output of curl, or only stderr if stdout is given
+func doCurl(ctx context.Context, commandArgs []string, stdout io.Writer) ([]byte, error) {
 	commandName := "curl"
-	cmd := exec.Command(commandName, commandArgs...)
+	cmd := exec.CommandContext(ctx, commandName, commandArgs...)
 	log.Debug().Msgf("Executing: %s", cmd.String())
-	out, err := cmd.CombinedOutput()
+	var out []byte
+	err := doWithResources(ctx, []scheduler.Resource{scheduler.Http, scheduler.Process}, func() error {
+		if stdout == nil {
+			var err error
+			out, err = cmd.CombinedOutput()
+			return err
+		}
+		var stderr bytes.Buffer
+		cmd.Stdout = stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		out = stderr.Bytes()
+		return err
+	})
 	return out, err
 }
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"errors"
+	"filippo.io/age"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const EncryptedFileExtension = ".age"
+
+var encryption = struct {
+	mu         sync.Mutex
+	recipients []age.Recipient
+}{}
+
+// ConfigureEncryption reads the age recipients (public keys like age1...) from path. The archive is encrypted for all
+// of them. An empty path disables encryption.
+func ConfigureEncryption(path string) error {
+	var recipients []age.Recipient
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open public key file '%s': %w", path, err)
+		}
+		defer file.Close()
+		recipients, err = age.ParseRecipients(file)
+		if err != nil {
+			return fmt.Errorf("failed to parse public key file '%s': %w", path, err)
+		}
+	}
+
+	encryption.mu.Lock()
+	defer encryption.mu.Unlock()
+	encryption.recipients = recipients
+	return nil
+}
+
+func encryptionRecipients() []age.Recipient {
+	encryption.mu.Lock()
+	defer encryption.mu.Unlock()
+	return encryption.recipients
+}
+
+// encryptingWriter returns w if encryption is disabled. Otherwise, everything written to the returned writer is
+// encrypted in chunks, and it must be closed to write the last one.
+func encryptingWriter(w io.Writer) (io.WriteCloser, error) {
+	recipients := encryptionRecipients()
+	if len(recipients) == 0 {
+		return nopWriteCloser{w}, nil
+	}
+	return age.Encrypt(w, recipients...)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// DecryptFile decrypts source with one of the age identities (private keys) in identityPath and writes the result to
+// target. The target is removed again if decryption fails.
+func DecryptFile(identityPath string, source string, target string) (err error) {
+	identityFile, err := os.Open(identityPath)
+	if err != nil {
+		return fmt.Errorf("failed to open identity file '%s': %w", identityPath, err)
+	}
+	defer identityFile.Close()
+	identities, err := age.ParseIdentities(identityFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse identity file '%s': %w", identityPath, err)
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	r, err := age.Decrypt(in, identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return fmt.Errorf("'%s' was not encrypted for any key in '%s'", source, identityPath)
+		}
+		return fmt.Errorf("failed to decrypt '%s': %w", source, err)
+	}
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(target)
+		}
+	}()
+	if _, err = io.Copy(out, r); err != nil {
+		return fmt.Errorf("failed to decrypt '%s': %w", source, err)
+	}
+	return nil
+}
+
+// DecryptedFileName strips the .age extension, e.g. steadybit-debug-123.tar.gz.age -> steadybit-debug-123.tar.gz
+func DecryptedFileName(source string) string {
+	if strings.HasSuffix(source, EncryptedFileExtension) {
+		return strings.TrimSuffix(source, EncryptedFileExtension)
+	}
+	return source + ".decrypted"
+}
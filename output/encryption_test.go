@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"bytes"
+	"filippo.io/age"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeys := filepath.Join(dir, "recipients.txt")
+	writeFile(t, publicKeys, "# support\n"+identity.Recipient().String()+"\n"+other.Recipient().String()+"\n")
+	identityPath := filepath.Join(dir, "key.txt")
+	writeFile(t, identityPath, identity.String()+"\n")
+	strangerPath := filepath.Join(dir, "stranger.txt")
+	writeFile(t, strangerPath, stranger.String()+"\n")
+
+	if err := ConfigureEncryption(publicKeys); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigureEncryption("")
+
+	// bigger than one age chunk of 64 KiB
+	content := bytes.Repeat([]byte("steadybit-debug archive content\n"), 5000)
+	source := filepath.Join(dir, "steadybit-debug-1.tar.gz"+EncryptedFileExtension)
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := encryptingWriter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	encrypted, _ := os.ReadFile(source)
+	if bytes.Contains(encrypted, []byte("steadybit-debug archive content")) {
+		t.Fatal("the archive is not encrypted")
+	}
+
+	target := DecryptedFileName(source)
+	if target != filepath.Join(dir, "steadybit-debug-1.tar.gz") {
+		t.Errorf("got decrypted file name %s", target)
+	}
+	if err := DecryptFile(identityPath, source, target); err != nil {
+		t.Fatal(err)
+	}
+	decrypted, _ := os.ReadFile(target)
+	if !bytes.Equal(decrypted, content) {
+		t.Errorf("decrypted %d bytes, want %d", len(decrypted), len(content))
+	}
+
+	if err := DecryptFile(identityPath, source, target); err == nil {
+		t.Error("existing target overwritten")
+	}
+	err = DecryptFile(strangerPath, source, filepath.Join(dir, "stranger.tar.gz"))
+	if err == nil || !strings.Contains(err.Error(), "was not encrypted for any key") {
+		t.Errorf("got %v for a foreign key", err)
+	}
+
+	truncated := filepath.Join(dir, "truncated.tar.gz.age")
+	writeFile(t, truncated, string(encrypted[:len(encrypted)-100]))
+	if err := DecryptFile(identityPath, truncated, filepath.Join(dir, "truncated.tar.gz")); err == nil {
+		t.Error("truncated archive decrypted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "truncated.tar.gz")); !os.IsNotExist(err) {
+		t.Error("target of the failed decryption not removed")
+	}
+}
+
+func TestEncryptionDisabled(t *testing.T) {
+	if err := ConfigureEncryption(""); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w, err := encryptingWriter(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("plain"))
+	w.Close()
+	if out.String() != "plain" {
+		t.Errorf("got %q", out.String())
+	}
+	if err := ConfigureEncryption(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("missing public key file accepted")
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
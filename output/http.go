@@ -6,12 +6,14 @@ package output
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/steadybit-debug/config"
+	"github.com/steadybit/steadybit-debug/scheduler"
 	"io"
 	"net/http"
 	"net/url"
@@ -36,16 +38,30 @@ type HttpOptions struct {
 	URL        url.URL
 	UseHttps   bool
 	FormatJson bool
+	// ExecutionContext identifies the pod the request is sent to
+	ExecutionContext string
 }
 
-func AddHttpOutput(opts AddHttpOutputOptions) {
+func AddHttpOutput(ctx context.Context, opts AddHttpOutputOptions) {
+	if DryRun() {
+		AddPlannedStep(ctx, PlannedStep{
+			Target:     opts.ExecutionContext,
+			Kind:       ArtifactKindHttp,
+			Command:    fmt.Sprintf("%s %s", opts.Method, opts.URL.String()),
+			OutputPath: opts.OutputPath,
+		})
+		return
+	}
+	if skipCancelled(ctx, Artifact{Path: opts.OutputPath, Target: opts.ExecutionContext, Kind: ArtifactKindHttp, Command: fmt.Sprintf("%s %s", opts.Method, opts.URL.String())}) {
+		return
+	}
 	start := time.Now()
 	outputPath := opts.OutputPath
 
 	content := fmt.Sprintf("# Executed command: %s %s", opts.Method, opts.URL.String())
 	content = fmt.Sprintf("%s\n# Started at: %s", content, time.Now().Format(time.RFC3339))
 
-	out, err := DoHttp(HttpOptions{
+	out, err := DoHttp(ctx, HttpOptions{
 		Config:     opts.Config,
 		Method:     opts.Method,
 		URL:        opts.URL,
@@ -58,7 +74,7 @@ func AddHttpOutput(opts AddHttpOutputOptions) {
 	}
 	if strings.Contains(string(out), "Client sent an HTTP request to an HTTPS server") {
 		opts.UseHttps = true
-		out, err = DoHttp(HttpOptions{
+		out, err = DoHttp(ctx, HttpOptions{
 			Config:     opts.Config,
 			Method:     opts.Method,
 			URL:        opts.URL,
@@ -71,21 +87,41 @@ func AddHttpOutput(opts AddHttpOutputOptions) {
 		}
 	}
 	content = fmt.Sprintf("%s\n\n%s", content, out)
+	incomplete := CancellationReason(ctx)
+	if incomplete != "" {
+		content = fmt.Sprintf("%s\n\n# Incomplete: %s", content, incomplete)
+	}
 
 	totalTime := time.Now().Sub(start)
 	content = fmt.Sprintf("%s\n\n# Total execution time: %d millis", content, totalTime.Milliseconds())
 
 	WriteToFile(outputPath, []byte(strings.TrimSpace(content)))
+	recordArtifact(ctx, Artifact{
+		Path:       outputPath,
+		Target:     opts.ExecutionContext,
+		Kind:       ArtifactKindHttp,
+		Command:    fmt.Sprintf("%s %s", opts.Method, opts.URL.String()),
+		Incomplete: incomplete,
+	}, start, err)
 }
 
-func DoHttp(options HttpOptions) ([]byte, error) {
-	body, err := doHttp(options)
+// DoHttp returns ErrDryRun in dry-run mode
+func DoHttp(ctx context.Context, options HttpOptions) ([]byte, error) {
+	if DryRun() {
+		AddPlannedStep(ctx, PlannedStep{
+			Target:  options.ExecutionContext,
+			Kind:    ArtifactKindHttp,
+			Command: fmt.Sprintf("%s %s", options.Method, options.URL.String()),
+		})
+		return nil, ErrDryRun
+	}
+	body, err := doHttp(ctx, options)
 	if err != nil {
 		return nil, err
 	}
 	if strings.Contains(string(body), "Client sent an HTTP request to an HTTPS server") {
 		options.UseHttps = true
-		body, err = doHttp(options)
+		body, err = doHttp(ctx, options)
 		if err != nil {
 			return nil, err
 		}
@@ -101,7 +137,7 @@ func DoHttp(options HttpOptions) ([]byte, error) {
 	return body, nil
 }
 
-func doHttp(options HttpOptions) ([]byte, error) {
+func doHttp(ctx context.Context, options HttpOptions) ([]byte, error) {
 	var tr *http.Transport
 	if options.UseHttps {
 		tr = &http.Transport{
@@ -134,10 +170,16 @@ func doHttp(options HttpOptions) ([]byte, error) {
 	}
 
 	client := &http.Client{Transport: tr}
-	var req = &http.Request{
-		Method: options.Method,
-		URL:    &options.URL,
+	req, err := http.NewRequestWithContext(ctx, options.Method, options.URL.String(), nil)
+	if err != nil {
+		return nil, err
 	}
+	release, err := scheduler.Acquire(ctx, scheduler.Http)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	response, err := client.Do(req)
 	defer closeResponse(response)
 	if err != nil {
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+type IncompleteArtifact struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// IncompleteArtifacts returns all artifacts that were cut short because the run or collector got cancelled
+func IncompleteArtifacts() []IncompleteArtifact {
+	result := []IncompleteArtifact{}
+	for _, artifact := range Artifacts() {
+		if artifact.Status == ArtifactIncomplete {
+			result = append(result, IncompleteArtifact{Path: artifact.Path, Reason: artifact.Incomplete})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// CancellationReason describes why the context got cancelled, e.g. "received signal interrupt", or returns an empty string
+func CancellationReason(ctx context.Context) string {
+	if ctx.Err() == nil {
+		return ""
+	}
+	return context.Cause(ctx).Error()
+}
+
+// skipCancelled returns true and records the artifact as skipped if it should not be started because the context got cancelled
+func skipCancelled(ctx context.Context, artifact Artifact) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	Skip(ctx, artifact, CancellationReason(ctx))
+	return true
+}
+
+// sleep waits for the given duration and returns false if the context got cancelled in the meantime
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
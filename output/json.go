@@ -1,10 +1,12 @@
 package output
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/steadybit/steadybit-debug/config"
 	"path/filepath"
+	"time"
 )
 
 type AddJsonOutputOptions struct {
@@ -14,6 +16,10 @@ type AddJsonOutputOptions struct {
 }
 
 func AddJsonOutput(opts AddJsonOutputOptions) {
+	if DryRun() {
+		return
+	}
+	start := time.Now()
 	json, err := json.MarshalIndent(opts.Content, "", "\t")
 
 	content := ""
@@ -24,4 +30,5 @@ func AddJsonOutput(opts AddJsonOutputOptions) {
 
 	outputFilePath := filepath.Join(opts.Config.OutputPath, filepath.Join(opts.OutputPath...))
 	WriteToFile(outputFilePath, []byte(content))
+	recordArtifact(context.Background(), Artifact{Path: outputFilePath, Kind: ArtifactKindFile}, start, err)
 }
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ManifestFileName = "manifest.json"
+
+	ArtifactSucceeded  = "succeeded"
+	ArtifactFailed     = "failed"
+	ArtifactIncomplete = "incomplete"
+	ArtifactSkipped    = "skipped"
+
+	ArtifactKindCommand  = "command"
+	ArtifactKindApi      = "api"
+	ArtifactKindHttp     = "http"
+	ArtifactKindDownload = "download"
+	ArtifactKindFile     = "file"
+)
+
+type Manifest struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// Artifact describes a single file of the bundle. Files that weren't written via this package, e.g. log.txt, only
+// carry path, kind, status, size and checksum.
+type Artifact struct {
+	// Path is relative to the bundle root
+	Path      string `json:"path"`
+	Collector string `json:"collector,omitempty"`
+	// Target is the pod or node the artifact was collected from, e.g. 'steadybit-agent/steadybit-agent-0'
+	Target string `json:"target,omitempty"`
+	Kind   string `json:"kind"`
+	// Command is the executed command, API request or HTTP method and URL
+	Command        string     `json:"command,omitempty"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	DurationMillis int64      `json:"durationMillis"`
+	Status         string     `json:"status"`
+	ExitCode       *int       `json:"exitCode,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	// Incomplete is the reason why the collection was cut short, e.g. 'received signal interrupt'
+	Incomplete string `json:"incomplete,omitempty"`
+	// Skipped is the reason why the artifact was not collected at all
+	Skipped string `json:"skipped,omitempty"`
+	Size    int64  `json:"size"`
+	Sha256  string `json:"sha256,omitempty"`
+	// TruncatedBytes were cut from the start of the artifact to fit the maximum bundle size
+	TruncatedBytes int64 `json:"truncatedBytes,omitempty"`
+	// Truncatable artifacts may be truncated by EnforceBundleSize
+	Truncatable bool `json:"-"`
+}
+
+type collectorContextKey struct{}
+
+// WithCollector attributes all artifacts written with the returned context to the given collector
+func WithCollector(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, collectorContextKey{}, name)
+}
+
+// CollectorFrom returns the collector set via WithCollector or an empty string
+func CollectorFrom(ctx context.Context) string {
+	name, _ := ctx.Value(collectorContextKey{}).(string)
+	return name
+}
+
+var artifacts = struct {
+	mu      sync.Mutex
+	entries map[string]Artifact
+}{entries: map[string]Artifact{}}
+
+// recordArtifact stores the outcome of collecting the artifact at artifact.Path for the manifest
+func recordArtifact(ctx context.Context, artifact Artifact, start time.Time, err error) {
+	artifact.Path = filepath.Clean(artifact.Path)
+	artifact.Collector = CollectorFrom(ctx)
+	artifact.StartedAt = &start
+	artifact.DurationMillis = time.Since(start).Milliseconds()
+	if err != nil {
+		artifact.Error = err.Error()
+	}
+	switch {
+	case artifact.Skipped != "":
+		artifact.Status = ArtifactSkipped
+	case artifact.Incomplete != "":
+		artifact.Status = ArtifactIncomplete
+	case err != nil:
+		artifact.Status = ArtifactFailed
+	default:
+		artifact.Status = ArtifactSucceeded
+	}
+
+	artifacts.mu.Lock()
+	defer artifacts.mu.Unlock()
+	artifacts.entries[artifact.Path] = artifact
+}
+
+// RecordFailure adds an artifact that could not be collected at all to the manifest, e.g. because the port-forward failed
+func RecordFailure(ctx context.Context, artifact Artifact, err error) {
+	recordArtifact(ctx, artifact, time.Now(), err)
+}
+
+// Skip adds an artifact that was deliberately not collected to the manifest
+func Skip(ctx context.Context, artifact Artifact, reason string) {
+	log.Debug().Msgf("Skipping '%s': %s", artifact.Path, reason)
+	artifact.Skipped = reason
+	recordArtifact(ctx, artifact, time.Now(), nil)
+}
+
+// Artifacts returns all artifacts recorded so far. Paths are not yet relative to the bundle root.
+func Artifacts() []Artifact {
+	artifacts.mu.Lock()
+	defer artifacts.mu.Unlock()
+	result := make([]Artifact, 0, len(artifacts.entries))
+	for _, artifact := range artifacts.entries {
+		result = append(result, artifact)
+	}
+	return result
+}
+
+// markTruncated records that removed bytes were cut from the artifact at path
+func markTruncated(path string, removed int64) {
+	artifacts.mu.Lock()
+	defer artifacts.mu.Unlock()
+	if artifact, ok := artifacts.entries[path]; ok {
+		artifact.TruncatedBytes = removed
+		artifacts.entries[path] = artifact
+	}
+}
+
+// anonymizeArtifacts applies path to the paths and text to all other descriptive fields of the recorded artifacts
+func anonymizeArtifacts(path func(string) string, text func(string) string) {
+	artifacts.mu.Lock()
+	defer artifacts.mu.Unlock()
+	entries := make(map[string]Artifact, len(artifacts.entries))
+	for _, artifact := range artifacts.entries {
+		artifact.Path = path(artifact.Path)
+		artifact.Target = text(artifact.Target)
+		artifact.Command = text(artifact.Command)
+		artifact.Error = text(artifact.Error)
+		artifact.Incomplete = text(artifact.Incomplete)
+		artifact.Skipped = text(artifact.Skipped)
+		entries[artifact.Path] = artifact
+	}
+	artifacts.entries = entries
+}
+
+// AddManifest writes manifest.json listing all files of the output directory. It must be called after all other
+// output was written, because sizes and checksums are calculated from the files on disk.
+func AddManifest(cfg *config.Config) {
+	log.Debug().Msgf("Adding manifest")
+	manifest := Manifest{
+		Version:   1,
+		CreatedAt: time.Now(),
+		Artifacts: []Artifact{},
+	}
+
+	recorded := map[string]Artifact{}
+	for _, artifact := range Artifacts() {
+		recorded[artifact.Path] = artifact
+	}
+
+	manifestPath := filepath.Join(cfg.OutputPath, ManifestFileName)
+	files, err := bundleFiles(cfg)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to list output directory '%s' for the manifest", cfg.OutputPath)
+	}
+	for _, file := range files {
+		if file.path == manifestPath {
+			continue
+		}
+		artifact, ok := recorded[file.path]
+		if !ok {
+			artifact = Artifact{Kind: ArtifactKindFile, Status: ArtifactSucceeded}
+		}
+		delete(recorded, file.path)
+		artifact.Size, artifact.Sha256 = file.size, file.sha256
+		if file.err != nil {
+			log.Debug().Err(file.err).Msgf("Failed to calculate checksum of '%s'", file.path)
+		}
+		manifest.Artifacts = append(manifest.Artifacts, withRelativePath(cfg, file.path, artifact))
+	}
+	// artifacts that failed before anything got written
+	for path, artifact := range recorded {
+		manifest.Artifacts = append(manifest.Artifacts, withRelativePath(cfg, path, artifact))
+	}
+	sort.Slice(manifest.Artifacts, func(i, j int) bool {
+		return manifest.Artifacts[i].Path < manifest.Artifacts[j].Path
+	})
+
+	content, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to serialize manifest")
+		return
+	}
+	WriteToFile(manifestPath, content)
+}
+
+func withRelativePath(cfg *config.Config, path string, artifact Artifact) Artifact {
+	artifact.Path = path
+	if relative, err := filepath.Rel(cfg.OutputPath, path); err == nil {
+		artifact.Path = filepath.ToSlash(relative)
+	}
+	return artifact
+}
+
+func checksum(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return size, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
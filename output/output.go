@@ -5,15 +5,33 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/steadybit/steadybit-debug/config"
 	"os"
-	"os/exec"
 	"path"
-	"path/filepath"
 	"time"
 )
 
+// createdAt is the time of the bundle, used as modification time of all archive entries
+var createdAt time.Time
+
+func bundleTime() time.Time {
+	if createdAt.IsZero() {
+		return time.Now().Truncate(time.Second)
+	}
+	return createdAt
+}
+
 func AddOutputDirectory(cfg *config.Config) {
-	directoryName := fmt.Sprintf("steadybit-debug-%d", time.Now().Unix())
+	createdAt = time.Now().Truncate(time.Second)
+	directoryName := fmt.Sprintf("steadybit-debug-%d", createdAt.Unix())
 	cfg.OutputPath = path.Join(cfg.OutputPath, directoryName)
+	if cfg.Stream {
+		err := startStream(cfg)
+		if err != nil {
+			log.Error().Msgf("Failed to create archive '%s' for debugging information: %s", ArchivePath(cfg), err)
+			os.Exit(1)
+		}
+		log.Info().Msgf("Debugging output will be streamed to %s", ArchivePath(cfg))
+		return
+	}
 	err := os.Mkdir(cfg.OutputPath, os.ModePerm)
 	if err != nil {
 		log.Error().Msgf("Failed create target directory '%s' for debugging information: %s", cfg.OutputPath, err)
@@ -27,23 +45,60 @@ func AddOutputDirectory(cfg *config.Config) {
 	log.Info().Msgf("Debugging output will be collected at %s%s", cfg.OutputPath, hint)
 }
 
-func ZipOutputDirectory(cfg *config.Config) string {
-	targetPath := fmt.Sprintf("%s.tar.gz", cfg.OutputPath)
-	cwd := filepath.Join(cfg.OutputPath, "..")
-	// Use relative paths for the last argument to `tar` so that the paths within tar are nice and short
-	relativeOutputPath, _ := filepath.Rel(cwd, cfg.OutputPath)
-	cmd := exec.Command("tar", "-czf", targetPath, relativeOutputPath)
-	cmd.Dir = cwd
-	err := cmd.Run()
+// ArchiveOutputDirectory writes the output directory to an archive in the configured format next to it and returns
+// its path, or the path of the index of its chunks. When streaming, it completes the archive instead.
+func ArchiveOutputDirectory(cfg *config.Config) (string, error) {
+	var targetPath string
+	var err error
+	if streaming() {
+		targetPath, err = finishStream()
+	} else {
+		targetPath, err = writeArchive(cfg)
+	}
 	if err != nil {
-		log.Error().Msgf("Failed turn target directory '%s' into tar archive at '%s'. Got error: %s", cfg.OutputPath, targetPath, err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to create archive of '%s': %w", cfg.OutputPath, err)
+	}
+	switch {
+	case archiveToStdout:
+		log.Info().Msgf("Debugging output written to stdout")
+	case cfg.SplitSize > 0:
+		targetPath += SplitIndexSuffix
+		log.Info().Msgf("Debugging output collected in chunks of at most %s, listed in: %s", cfg.SplitSize, targetPath)
+	case len(encryptionRecipients()) > 0:
+		log.Info().Msgf("Debugging output collected and encrypted at: %s", targetPath)
+	default:
+		log.Info().Msgf("Debugging output collected at: %s", targetPath)
+	}
+	return targetPath, nil
+}
+
+func writeArchive(cfg *config.Config) (string, error) {
+	archive, err := createArchive(cfg)
+	if err != nil {
+		return "", err
+	}
+	err = addDirectory(archive, cfg.OutputPath)
+	if err != nil {
+		archive.abort()
+		return "", err
 	}
-	log.Info().Msgf("Debugging output collected at: %s", targetPath)
-	return targetPath
+	if err = archive.Close(); err != nil {
+		archive.abort()
+		return "", err
+	}
+	return archive.path, nil
 }
 
+// WriteToFile masks secrets in content before writing it. It does nothing in dry-run mode.
 func WriteToFile(path string, content []byte) {
-	os.MkdirAll(filepath.Dir(path), os.ModePerm)
-	os.WriteFile(path, content, 0666)
+	if DryRun() {
+		return
+	}
+	file, err := CreateFile(path)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to create '%s'", path)
+		return
+	}
+	defer file.Close()
+	file.Write(redactContent(path, content))
 }
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	PlanKindPortForward        = "port-forward"
+	PlanKindEphemeralContainer = "ephemeral-container"
+	PlanKindProbePod           = "probe-pod"
+)
+
+// ErrDryRun is returned instead of a response for requests that are not executed in dry-run mode
+var ErrDryRun = errors.New("not executed in dry-run mode")
+
+// PlannedStep is an action that would be executed without --dry-run
+type PlannedStep struct {
+	Collector string `json:"collector,omitempty"`
+	Target    string `json:"target,omitempty"`
+	// Kind is one of the artifact kinds, PlanKindPortForward, PlanKindEphemeralContainer or PlanKindProbePod
+	Kind       string `json:"kind"`
+	Command    string `json:"command"`
+	Image      string `json:"image,omitempty"`
+	OutputPath string `json:"outputPath,omitempty"`
+	Executions int    `json:"executions,omitempty"`
+}
+
+var plan = struct {
+	mu      sync.Mutex
+	enabled bool
+	steps   []PlannedStep
+}{}
+
+// EnableDryRun makes all output functions record what they would do instead of doing it. Must be called before any
+// collector runs.
+func EnableDryRun() {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	plan.enabled = true
+}
+
+// DryRun returns true if steps are only planned but not executed
+func DryRun() bool {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	return plan.enabled
+}
+
+// AddPlannedStep records a step of the collection plan for the collector of ctx
+func AddPlannedStep(ctx context.Context, step PlannedStep) {
+	step.Collector = CollectorFrom(ctx)
+	if step.Executions == 1 {
+		step.Executions = 0
+	}
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	plan.steps = append(plan.steps, step)
+}
+
+// PlannedSteps returns all planned steps ordered by collector, target, port-forwards first, and output path
+func PlannedSteps() []PlannedStep {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+	result := append(make([]PlannedStep, 0, len(plan.steps)), plan.steps...)
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Collector != b.Collector {
+			return a.Collector < b.Collector
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		// port-forwards are set up before anything else
+		if (a.Kind == PlanKindPortForward) != (b.Kind == PlanKindPortForward) {
+			return a.Kind == PlanKindPortForward
+		}
+		if a.OutputPath != b.OutputPath {
+			return a.OutputPath < b.OutputPath
+		}
+		return a.Command < b.Command
+	})
+	return result
+}
+
+// WritePlanTree writes the planned steps as a tree of collectors, targets and steps. notes are shown next to the
+// collector, e.g. why nothing got planned for it.
+func WritePlanTree(w io.Writer, collectors []string, notes map[string]string) {
+	steps := PlannedSteps()
+	fmt.Fprintln(w, "Collection plan (dry-run, nothing was executed):")
+	for _, collector := range collectors {
+		line := collector
+		if note := notes[collector]; note != "" {
+			line = fmt.Sprintf("%s (%s)", collector, note)
+		}
+		fmt.Fprintln(w, line)
+
+		var targets []string
+		byTarget := map[string][]PlannedStep{}
+		for _, step := range steps {
+			if step.Collector != collector {
+				continue
+			}
+			if _, ok := byTarget[step.Target]; !ok {
+				targets = append(targets, step.Target)
+			}
+			byTarget[step.Target] = append(byTarget[step.Target], step)
+		}
+		if len(targets) == 0 {
+			fmt.Fprintln(w, "└── nothing to do")
+		}
+		for i, target := range targets {
+			prefix, childPrefix := "├── ", "│   "
+			if i == len(targets)-1 {
+				prefix, childPrefix = "└── ", "    "
+			}
+			if target == "" {
+				target = "(no target)"
+			}
+			fmt.Fprintf(w, "%s%s\n", prefix, target)
+			for j, step := range byTarget[targets[i]] {
+				stepPrefix := "├── "
+				if j == len(byTarget[targets[i]])-1 {
+					stepPrefix = "└── "
+				}
+				fmt.Fprintf(w, "%s%s%s\n", childPrefix, stepPrefix, describeStep(step))
+			}
+		}
+	}
+}
+
+func describeStep(step PlannedStep) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", step.Kind, step.Command)
+	if step.Image != "" {
+		fmt.Fprintf(&b, " [image %s]", step.Image)
+	}
+	if step.Executions > 1 {
+		fmt.Fprintf(&b, " [%d times]", step.Executions)
+	}
+	if step.OutputPath != "" {
+		fmt.Fprintf(&b, " -> %s", step.OutputPath)
+	}
+	return b.String()
+}
@@ -0,0 +1,368 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	RedactionsFileName = "redactions.json"
+	RedactedValue      = "<redacted>"
+)
+
+var (
+	// builtInSecretKeys matches keys of configuration properties, environment variables and JSON/YAML fields holding secrets
+	builtInSecretKeys = regexp.MustCompile(`(?i)(passw(or)?d|pwd|secret|token|api[-_.]?key|agent[-_.]?key|access[-_.]?key|private[-_.]?key|signing[-_.]?key|encryption[-_.]?key|license[-_.]?key|credential|authorization|cookie)`)
+	// nonSecretKeys are keys that contain a secret-looking word but only refer to a secret, e.g. TOKEN_URL or secretName
+	nonSecretKeys = regexp.MustCompile(`(?i)(url|uri|path|file|endpoint|enabled|type|name|ref|expiry|expiration|ttl|timeout|length|size)$`)
+
+	jsonKeyValue         = regexp.MustCompile(`"([^"\\]+)"(\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+	actuatorPropertyJson = regexp.MustCompile(`"([^"\\]+)"(\s*:\s*\{\s*"value"\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+	plainKeyValue        = regexp.MustCompile(`^(\s*(?:-\s+)?)([A-Za-z0-9_.\-/\[\]]+)(\s*[:=]\s*)(\S.*?)\s*$`)
+	argumentKeyValue     = regexp.MustCompile(`(--?[A-Za-z0-9_.\-]+)(=)(\S+)`)
+	envName              = regexp.MustCompile(`^\s*-?\s*name:\s*"?([A-Za-z0-9_.\-]+)"?\s*$`)
+	envValue             = regexp.MustCompile(`^(\s*value:\s*)(\S.*?)\s*$`)
+
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+type valueRule struct {
+	name    string
+	pattern *regexp.Regexp
+	// group is the submatch to redact, 0 for the whole match
+	group int
+}
+
+var builtInValueRules = []valueRule{
+	{name: "jwt", pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)},
+	{name: "bearer-token", pattern: regexp.MustCompile(`(?i)bearer\s+([A-Za-z0-9._~+/\-]{8,}=*)`), group: 1},
+	{name: "url-credentials", pattern: regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.\-]*://[^/\s:@"']+:([^@\s/"']+)@`), group: 1},
+	{name: "aws-access-key", pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+}
+
+type redactor struct {
+	enabled    bool
+	keys       []*regexp.Regexp
+	valueRules []valueRule
+}
+
+var redaction = struct {
+	mu       sync.Mutex
+	redactor *redactor
+	findings map[string]map[string]*RedactionFinding
+}{
+	redactor: newDefaultRedactor(),
+	findings: map[string]map[string]*RedactionFinding{},
+}
+
+func newDefaultRedactor() *redactor {
+	return &redactor{
+		enabled:    true,
+		keys:       []*regexp.Regexp{builtInSecretKeys},
+		valueRules: builtInValueRules,
+	}
+}
+
+// ConfigureRedaction adds the user-defined key and value rules to the built-in ones or disables redaction
+func ConfigureRedaction(cfg config.RedactionConfig) error {
+	r := newDefaultRedactor()
+	r.enabled = !cfg.Disabled
+	for _, key := range cfg.Keys {
+		pattern, err := regexp.Compile(key)
+		if err != nil {
+			return fmt.Errorf("invalid redaction key '%s': %w", key, err)
+		}
+		r.keys = append(r.keys, pattern)
+	}
+	r.valueRules = append([]valueRule(nil), builtInValueRules...)
+	for _, value := range cfg.Patterns {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern '%s': %w", value, err)
+		}
+		r.valueRules = append(r.valueRules, valueRule{name: fmt.Sprintf("pattern %s", value), pattern: pattern})
+	}
+
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+	redaction.redactor = r
+	return nil
+}
+
+func currentRedactor() *redactor {
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+	return redaction.redactor
+}
+
+// RedactionFinding describes what got masked in a file. It never contains the masked values.
+type RedactionFinding struct {
+	Path  string   `json:"path"`
+	Rule  string   `json:"rule"`
+	Count int      `json:"count"`
+	Keys  []string `json:"keys,omitempty"`
+}
+
+func recordRedaction(path string, rule string, key string) {
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+	byRule, ok := redaction.findings[path]
+	if !ok {
+		byRule = map[string]*RedactionFinding{}
+		redaction.findings[path] = byRule
+	}
+	finding, ok := byRule[rule]
+	if !ok {
+		finding = &RedactionFinding{Path: path, Rule: rule}
+		byRule[rule] = finding
+	}
+	finding.Count++
+	if key != "" && !containsString(finding.Keys, key) {
+		finding.Keys = append(finding.Keys, key)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactionFindings returns what got redacted so far, ordered by path and rule
+func RedactionFindings() []RedactionFinding {
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+	result := []RedactionFinding{}
+	for _, byRule := range redaction.findings {
+		for _, finding := range byRule {
+			f := *finding
+			f.Keys = append([]string(nil), finding.Keys...)
+			sort.Strings(f.Keys)
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Rule < result[j].Rule
+	})
+	return result
+}
+
+// AddRedactionReport writes redactions.json listing which rules masked how many values in which file
+func AddRedactionReport(cfg *config.Config) {
+	if DryRun() {
+		return
+	}
+	findings := RedactionFindings()
+	total := 0
+	for i, finding := range findings {
+		total += finding.Count
+		if relative, err := filepath.Rel(cfg.OutputPath, finding.Path); err == nil {
+			findings[i].Path = filepath.ToSlash(relative)
+		}
+	}
+	if total > 0 {
+		log.Info().Msgf("Redacted %d secret values in %d files, see %s", total, countPaths(findings), RedactionsFileName)
+	}
+
+	content, err := json.MarshalIndent(map[string]any{
+		"enabled":  currentRedactor().enabled,
+		"findings": findings,
+	}, "", "\t")
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to serialize redaction report")
+		return
+	}
+	// the report itself must not be redacted, it only contains key names and rules
+	path := filepath.Join(cfg.OutputPath, RedactionsFileName)
+	file, err := CreateFile(path)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to write redaction report")
+		return
+	}
+	defer file.Close()
+	file.Write(content)
+}
+
+func countPaths(findings []RedactionFinding) int {
+	paths := map[string]bool{}
+	for _, finding := range findings {
+		paths[finding.Path] = true
+	}
+	return len(paths)
+}
+
+func (r *redactor) isSecretKey(key string) bool {
+	if nonSecretKeys.MatchString(key) {
+		return false
+	}
+	for _, pattern := range r.keys {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) keyRule(key string) string {
+	for i, pattern := range r.keys {
+		if pattern.MatchString(key) {
+			if i == 0 {
+				return "secret-key"
+			}
+			return fmt.Sprintf("key %s", pattern.String())
+		}
+	}
+	return "secret-key"
+}
+
+// lineRedactor masks secrets line by line. It keeps state across lines for Kubernetes env entries and PEM blocks.
+type lineRedactor struct {
+	r    *redactor
+	path string
+	// envSecretKey is set if the previous line was the name of a secret env var, e.g. '- name: AGENT_KEY'
+	envSecretKey string
+	inPrivateKey bool
+}
+
+func (l *lineRedactor) redactLine(line string) string {
+	if l.inPrivateKey {
+		if privateKeyEnd.MatchString(line) {
+			l.inPrivateKey = false
+			return line
+		}
+		recordRedaction(l.path, "private-key", "")
+		return RedactedValue
+	}
+	if privateKeyBegin.MatchString(line) && !privateKeyEnd.MatchString(line) {
+		l.inPrivateKey = true
+		return line
+	}
+
+	if l.envSecretKey != "" {
+		key := l.envSecretKey
+		l.envSecretKey = ""
+		if m := envValue.FindStringSubmatch(line); m != nil {
+			recordRedaction(l.path, l.r.keyRule(key), key)
+			return m[1] + RedactedValue
+		}
+	}
+	if m := envName.FindStringSubmatch(line); m != nil && l.r.isSecretKey(m[1]) {
+		l.envSecretKey = m[1]
+		return line
+	}
+
+	line = l.redactKeyValues(line)
+
+	for _, rule := range l.r.valueRules {
+		line = rule.pattern.ReplaceAllStringFunc(line, func(match string) string {
+			if strings.Contains(match, RedactedValue) {
+				return match
+			}
+			recordRedaction(l.path, rule.name, "")
+			if rule.group == 0 {
+				return RedactedValue
+			}
+			m := rule.pattern.FindStringSubmatchIndex(match)
+			return match[:m[2*rule.group]] + RedactedValue + match[m[2*rule.group+1]:]
+		})
+	}
+	return line
+}
+
+func (l *lineRedactor) redactKeyValues(line string) string {
+	replaceValue := func(pattern *regexp.Regexp, keyGroup int, valueGroup int) {
+		line = pattern.ReplaceAllStringFunc(line, func(match string) string {
+			m := pattern.FindStringSubmatchIndex(match)
+			key := match[m[2*keyGroup]:m[2*keyGroup+1]]
+			value := match[m[2*valueGroup]:m[2*valueGroup+1]]
+			if !l.r.isSecretKey(strings.TrimLeft(key, "-")) || value == "" || value == RedactedValue || strings.HasPrefix(value, "***") {
+				return match
+			}
+			recordRedaction(l.path, l.r.keyRule(key), strings.TrimLeft(key, "-"))
+			return match[:m[2*valueGroup]] + RedactedValue + match[m[2*valueGroup+1]:]
+		})
+	}
+
+	if strings.Contains(line, `"`) {
+		replaceValue(actuatorPropertyJson, 1, 3)
+		replaceValue(jsonKeyValue, 1, 3)
+	}
+	if m := plainKeyValue.FindStringSubmatch(line); m != nil && !strings.HasPrefix(m[4], "{") && !strings.HasPrefix(m[4], "[") {
+		replaceValue(plainKeyValue, 2, 4)
+	}
+	replaceValue(argumentKeyValue, 1, 3)
+	return line
+}
+
+// redactContent masks secrets in the content of the file at path
+func redactContent(path string, content []byte) []byte {
+	r := currentRedactor()
+	if !r.enabled {
+		return content
+	}
+	l := &lineRedactor{r: r, path: path}
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		lines[i] = l.redactLine(line)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// redactingWriter masks secrets of streamed content line by line. Flush must be called to write the last line.
+type redactingWriter struct {
+	w       io.Writer
+	l       *lineRedactor
+	pending []byte
+}
+
+func newRedactingWriter(w io.Writer, path string) *redactingWriter {
+	return &redactingWriter{w: w, l: &lineRedactor{r: currentRedactor(), path: path}}
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	if !rw.l.r.enabled {
+		return rw.w.Write(p)
+	}
+	rw.pending = append(rw.pending, p...)
+	for {
+		idx := bytes.IndexByte(rw.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		line := rw.l.redactLine(string(rw.pending[:idx]))
+		if _, err := io.WriteString(rw.w, line+"\n"); err != nil {
+			return 0, err
+		}
+		rw.pending = rw.pending[idx+1:]
+	}
+	return len(p), nil
+}
+
+func (rw *redactingWriter) Flush() error {
+	if len(rw.pending) == 0 {
+		return nil
+	}
+	line := rw.l.redactLine(string(rw.pending))
+	rw.pending = nil
+	_, err := io.WriteString(rw.w, line)
+	return err
+}
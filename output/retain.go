@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"github.com/steadybit/steadybit-debug/config"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// retained are the collected files that reports read back after the collection. When streaming, their content is kept
+// in memory as it is no longer available from the output directory.
+var retained = struct {
+	mu       sync.Mutex
+	patterns []string
+	files    map[string][]byte
+}{files: map[string][]byte{}}
+
+// RetainFiles registers path.Match patterns relative to the bundle root, e.g. 'agent/pods/*/info.yml', of files that are
+// read back via CollectedFiles. It must be called before the collection starts, e.g. from an init function.
+func RetainFiles(patterns ...string) {
+	retained.mu.Lock()
+	defer retained.mu.Unlock()
+	retained.patterns = append(retained.patterns, patterns...)
+}
+
+func isRetained(name string) bool {
+	for _, pattern := range retained.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// retainedEntry reports whether a streamed file is retained. name is relative to the parent of the output directory.
+func retainedEntry(name string) bool {
+	_, name, _ = strings.Cut(name, "/")
+	retained.mu.Lock()
+	defer retained.mu.Unlock()
+	return isRetained(name)
+}
+
+// retain keeps a copy of a streamed file if it is retained. name is relative to the parent of the output directory.
+func retain(name string, content []byte) {
+	_, name, _ = strings.Cut(name, "/")
+	retained.mu.Lock()
+	defer retained.mu.Unlock()
+	if isRetained(name) {
+		retained.files[name] = append([]byte{}, content...)
+	}
+}
+
+// CollectedFiles returns the content of the retained files collected so far by their slash-separated path relative
+// to the bundle root
+func CollectedFiles(cfg *config.Config) (map[string][]byte, error) {
+	// streaming locks the stream, which must not happen while holding retained.mu, see addStreamEntry
+	isStreaming := streaming()
+	retained.mu.Lock()
+	defer retained.mu.Unlock()
+	files := map[string][]byte{}
+	if isStreaming {
+		for name, content := range retained.files {
+			files[name] = content
+		}
+		return files, nil
+	}
+
+	err := filepath.WalkDir(cfg.OutputPath, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(cfg.OutputPath, p)
+		if err != nil || !isRetained(filepath.ToSlash(name)) {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = content
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SplitIndexSuffix is appended to the archive name for the index of its chunks, e.g. steadybit-debug-123.tar.gz.index.json
+const SplitIndexSuffix = ".index.json"
+
+// SplitIndex lists the chunks of a split archive. Names are relative to the directory of the index, so that all files
+// can be moved together.
+type SplitIndex struct {
+	Version int `json:"version"`
+	// Archive is the name of the joined archive
+	Archive string       `json:"archive"`
+	Size    int64        `json:"size"`
+	Sha256  string       `json:"sha256"`
+	Chunks  []SplitChunk `json:"chunks"`
+}
+
+type SplitChunk struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// splitTarget writes the archive into numbered chunks of at most chunkSize bytes while it is produced, e.g.
+// steadybit-debug-123.tar.gz.001, and the index on Close
+type splitTarget struct {
+	path      string
+	chunkSize int64
+	index     SplitIndex
+	hash      hash.Hash
+	chunk     *os.File
+	chunkHash hash.Hash
+	written   int64
+}
+
+func newSplitTarget(path string, chunkSize int64) *splitTarget {
+	return &splitTarget{
+		path:      path,
+		chunkSize: chunkSize,
+		index:     SplitIndex{Version: 1, Archive: filepath.Base(path), Chunks: []SplitChunk{}},
+		hash:      sha256.New(),
+	}
+}
+
+func (t *splitTarget) Write(p []byte) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		if t.chunk == nil || t.written == t.chunkSize {
+			if err := t.nextChunk(); err != nil {
+				return total, err
+			}
+		}
+		n := int(min(int64(len(p)), t.chunkSize-t.written))
+		n, err := t.chunk.Write(p[:n])
+		t.chunkHash.Write(p[:n])
+		t.hash.Write(p[:n])
+		t.written += int64(n)
+		t.index.Size += int64(n)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+	}
+	return total, nil
+}
+
+func (t *splitTarget) nextChunk() error {
+	if err := t.closeChunk(); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.%03d", t.path, len(t.index.Chunks)+1)
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	t.chunk = file
+	t.chunkHash = sha256.New()
+	t.written = 0
+	t.index.Chunks = append(t.index.Chunks, SplitChunk{Name: filepath.Base(name)})
+	return nil
+}
+
+func (t *splitTarget) closeChunk() error {
+	if t.chunk == nil {
+		return nil
+	}
+	err := t.chunk.Close()
+	t.chunk = nil
+	last := &t.index.Chunks[len(t.index.Chunks)-1]
+	last.Size = t.written
+	last.Sha256 = hex.EncodeToString(t.chunkHash.Sum(nil))
+	return err
+}
+
+// Close completes the last chunk and writes the index
+func (t *splitTarget) Close() error {
+	if err := t.closeChunk(); err != nil {
+		return err
+	}
+	t.index.Sha256 = hex.EncodeToString(t.hash.Sum(nil))
+	content, err := json.MarshalIndent(t.index, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path+SplitIndexSuffix, content, 0666)
+}
+
+func (t *splitTarget) remove() {
+	if t.chunk != nil {
+		t.chunk.Close()
+		t.chunk = nil
+	}
+	dir := filepath.Dir(t.path)
+	for _, chunk := range t.index.Chunks {
+		os.Remove(filepath.Join(dir, chunk.Name))
+	}
+	os.Remove(t.path + SplitIndexSuffix)
+}
+
+// JoinedFileName returns the name of the archive of a split index, e.g. steadybit-debug-123.tar.gz.index.json ->
+// steadybit-debug-123.tar.gz
+func JoinedFileName(indexPath string) string {
+	if strings.HasSuffix(indexPath, SplitIndexSuffix) {
+		return strings.TrimSuffix(indexPath, SplitIndexSuffix)
+	}
+	return indexPath + ".joined"
+}
+
+// JoinChunks verifies the chunks listed in the index at indexPath and concatenates them to target. Nothing is written
+// if a chunk is missing or has the wrong size or checksum, and the target is removed again if joining fails.
+func JoinChunks(indexPath string, target string) (err error) {
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		return err
+	}
+	var index SplitIndex
+	if err = json.Unmarshal(content, &index); err != nil {
+		return fmt.Errorf("failed to parse index '%s': %w", indexPath, err)
+	}
+	if len(index.Chunks) == 0 {
+		return fmt.Errorf("index '%s' lists no chunks", indexPath)
+	}
+
+	dir := filepath.Dir(indexPath)
+	var errs []error
+	for _, chunk := range index.Chunks {
+		if err := verifyChunk(filepath.Join(dir, chunk.Name), chunk); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(target)
+		}
+	}()
+	hash := sha256.New()
+	w := io.MultiWriter(out, hash)
+	for _, chunk := range index.Chunks {
+		if err = appendFile(w, filepath.Join(dir, chunk.Name)); err != nil {
+			return err
+		}
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != index.Sha256 {
+		return fmt.Errorf("checksum of the joined archive is %s, expected %s", sum, index.Sha256)
+	}
+	return nil
+}
+
+func verifyChunk(path string, chunk SplitChunk) error {
+	size, sum, err := checksum(path)
+	if err != nil {
+		return fmt.Errorf("chunk '%s': %w", chunk.Name, err)
+	}
+	if size != chunk.Size {
+		return fmt.Errorf("chunk '%s' has %d bytes, expected %d", chunk.Name, size, chunk.Size)
+	}
+	if sum != chunk.Sha256 {
+		return fmt.Errorf("chunk '%s' has checksum %s, expected %s", chunk.Name, sum, chunk.Sha256)
+	}
+	return nil
+}
+
+func appendFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
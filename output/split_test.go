@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitAndJoin(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		chunkSize int64
+		writeSize int
+		chunks    []int64
+	}{
+		{name: "remainder in the last chunk", size: 2500, chunkSize: 1000, writeSize: 300, chunks: []int64{1000, 1000, 500}},
+		{name: "exact multiple", size: 2000, chunkSize: 1000, writeSize: 2000, chunks: []int64{1000, 1000}},
+		{name: "smaller than a chunk", size: 10, chunkSize: 1000, writeSize: 1, chunks: []int64{10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := make([]byte, tt.size)
+			for i := range content {
+				content[i] = byte(i * 7)
+			}
+			path := filepath.Join(t.TempDir(), "steadybit-debug-1.tar.gz")
+			split := newSplitTarget(path, tt.chunkSize)
+			for i := 0; i < len(content); i += tt.writeSize {
+				if _, err := split.Write(content[i:min(i+tt.writeSize, len(content))]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := split.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(split.index.Chunks) != len(tt.chunks) {
+				t.Fatalf("got %d chunks, want %d", len(split.index.Chunks), len(tt.chunks))
+			}
+			for i, chunk := range split.index.Chunks {
+				if chunk.Size != tt.chunks[i] {
+					t.Errorf("chunk %s has %d bytes, want %d", chunk.Name, chunk.Size, tt.chunks[i])
+				}
+			}
+			if name := split.index.Chunks[0].Name; name != "steadybit-debug-1.tar.gz.001" {
+				t.Errorf("got chunk name %s", name)
+			}
+
+			indexPath := path + SplitIndexSuffix
+			target := JoinedFileName(indexPath)
+			if target != path {
+				t.Errorf("got joined file name %s", target)
+			}
+			if err := JoinChunks(indexPath, target); err != nil {
+				t.Fatal(err)
+			}
+			joined, _ := os.ReadFile(target)
+			if !bytes.Equal(joined, content) {
+				t.Errorf("joined %d bytes, want %d", len(joined), len(content))
+			}
+		})
+	}
+}
+
+func TestJoinChunksRejectsDamagedChunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		damage  func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{
+			name: "corrupted chunk",
+			damage: func(t *testing.T, dir string) {
+				chunk := filepath.Join(dir, "steadybit-debug-1.tar.gz.002")
+				content, _ := os.ReadFile(chunk)
+				content[10] ^= 0xff
+				writeFile(t, chunk, string(content))
+			},
+			wantErr: "chunk 'steadybit-debug-1.tar.gz.002' has checksum",
+		},
+		{
+			name: "truncated chunk",
+			damage: func(t *testing.T, dir string) {
+				if err := os.Truncate(filepath.Join(dir, "steadybit-debug-1.tar.gz.001"), 10); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "chunk 'steadybit-debug-1.tar.gz.001' has 10 bytes, expected 100",
+		},
+		{
+			name: "missing chunk",
+			damage: func(t *testing.T, dir string) {
+				os.Remove(filepath.Join(dir, "steadybit-debug-1.tar.gz.003"))
+			},
+			wantErr: "chunk 'steadybit-debug-1.tar.gz.003'",
+		},
+		{
+			name: "index of another archive",
+			damage: func(t *testing.T, dir string) {
+				index := filepath.Join(dir, "steadybit-debug-1.tar.gz"+SplitIndexSuffix)
+				content, _ := os.ReadFile(index)
+				writeFile(t, index, strings.Replace(string(content), `"sha256": "`, `"sha256": "0`, 1))
+			},
+			wantErr: "checksum of the joined archive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "steadybit-debug-1.tar.gz")
+			split := newSplitTarget(path, 100)
+			split.Write(bytes.Repeat([]byte("0123456789"), 25))
+			if err := split.Close(); err != nil {
+				t.Fatal(err)
+			}
+			tt.damage(t, dir)
+
+			err := JoinChunks(path+SplitIndexSuffix, path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Error("joined archive was not removed")
+			}
+		})
+	}
+}
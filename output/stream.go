@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// stream writes files straight into the archive instead of the output directory, see config.Config.Stream
+var stream = struct {
+	mu      sync.Mutex
+	archive *archiveFile
+	// root is the parent of the output directory, entries are named relative to it
+	root  string
+	dirs  map[string]bool
+	files map[string]bundleFile
+	err   error
+}{}
+
+// bundleFile is a file of the bundle with the size and checksum it has in the archive
+type bundleFile struct {
+	path   string
+	size   int64
+	sha256 string
+	err    error
+}
+
+// startStream creates the archive that all files are written to as soon as they are complete
+func startStream(cfg *config.Config) error {
+	archive, err := createArchive(cfg)
+	if err != nil {
+		return err
+	}
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	stream.archive = archive
+	stream.root = filepath.Dir(cfg.OutputPath)
+	stream.dirs = map[string]bool{}
+	stream.files = map[string]bundleFile{}
+	return nil
+}
+
+func streaming() bool {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	return stream.archive != nil
+}
+
+// finishStream completes the streamed archive. The archive is removed if any file could not be added.
+func finishStream() (string, error) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	archive := stream.archive
+	stream.archive = nil
+	if stream.err != nil {
+		archive.abort()
+		return "", stream.err
+	}
+	if err := archive.Close(); err != nil {
+		archive.abort()
+		return "", err
+	}
+	return archive.path, nil
+}
+
+// CreateFile creates the file at path within the output directory. When streaming, the returned writer buffers the
+// content and adds it to the archive on Close. Otherwise, the file is opened for appending, so that files still being
+// written, like log.txt, continue at the end after anonymization or truncation rewrote them.
+func CreateFile(path string) (io.WriteCloser, error) {
+	if streaming() {
+		return &streamEntry{path: filepath.Clean(path)}, nil
+	}
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0666)
+}
+
+// spillThreshold is the size up to which a streamed file is held in memory, bigger ones like logs or the database
+// export are spilled to a temporary file until they are added to the archive
+const spillThreshold = 4 << 20
+
+// streamEntry is a file that is added to the streamed archive when it gets closed. Writes after Close are discarded,
+// e.g. log lines after log.txt was added.
+type streamEntry struct {
+	mu     sync.Mutex
+	path   string
+	buf    bytes.Buffer
+	spill  *os.File
+	size   int64
+	closed bool
+}
+
+func (e *streamEntry) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return len(p), nil
+	}
+	if e.spill == nil && e.buf.Len()+len(p) > spillThreshold {
+		spill, err := os.CreateTemp("", "steadybit-debug-stream-*")
+		if err != nil {
+			return 0, fmt.Errorf("failed to spill '%s' to a temporary file: %w", e.path, err)
+		}
+		e.spill = spill
+		if _, err = spill.Write(e.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		e.buf = bytes.Buffer{}
+	}
+	var n int
+	var err error
+	if e.spill != nil {
+		n, err = e.spill.Write(p)
+	} else {
+		n, err = e.buf.Write(p)
+	}
+	e.size += int64(n)
+	return n, err
+}
+
+// reset discards everything written so far, e.g. before a retry
+func (e *streamEntry) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.buf.Reset()
+	e.removeSpill()
+	e.size = 0
+}
+
+func (e *streamEntry) removeSpill() {
+	if e.spill != nil {
+		e.spill.Close()
+		os.Remove(e.spill.Name())
+		e.spill = nil
+	}
+}
+
+func (e *streamEntry) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	defer e.removeSpill()
+
+	var content io.Reader = bytes.NewReader(e.buf.Bytes())
+	if e.spill != nil {
+		if _, err := e.spill.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		content = e.spill
+	}
+	err := addStreamEntry(e.path, e.size, content)
+	e.buf = bytes.Buffer{}
+	return err
+}
+
+func addStreamEntry(filePath string, size int64, content io.Reader) error {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	if stream.archive == nil {
+		return fmt.Errorf("failed to add '%s': the archive is already complete", filePath)
+	}
+	name, err := filepath.Rel(stream.root, filePath)
+	if err != nil {
+		return err
+	}
+	name = filepath.ToSlash(name)
+
+	var dirs []string
+	for dir := path.Dir(name); dir != "." && !stream.dirs[dir]; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	for i := len(dirs) - 1; i >= 0 && err == nil; i-- {
+		stream.dirs[dirs[i]] = true
+		err = stream.archive.writeDir(dirs[i])
+	}
+
+	// the checksum and the retained copy are taken while the content is copied into the archive
+	hash := sha256.New()
+	writers := []io.Writer{hash}
+	var retainedCopy *bytes.Buffer
+	if retainedEntry(name) {
+		retainedCopy = &bytes.Buffer{}
+		writers = append(writers, retainedCopy)
+	}
+	if err == nil {
+		err = stream.archive.writeFile(name, size, io.TeeReader(content, io.MultiWriter(writers...)))
+	}
+	if err != nil {
+		// the archive is corrupt after a failed write
+		log.Error().Err(err).Msgf("Failed to add '%s' to the archive", filePath)
+		stream.err = errors.Join(stream.err, fmt.Errorf("failed to add '%s': %w", filePath, err))
+		return err
+	}
+
+	if retainedCopy != nil {
+		retain(name, retainedCopy.Bytes())
+	}
+	stream.files[filePath] = bundleFile{path: filePath, size: size, sha256: hex.EncodeToString(hash.Sum(nil))}
+	return nil
+}
+
+// bundleFiles returns the files of the bundle in lexical order, either from the output directory or the ones that
+// were added to the streamed archive
+func bundleFiles(cfg *config.Config) ([]bundleFile, error) {
+	if streaming() {
+		stream.mu.Lock()
+		defer stream.mu.Unlock()
+		files := make([]bundleFile, 0, len(stream.files))
+		for _, file := range stream.files {
+			files = append(files, file)
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+		return files, nil
+	}
+
+	var files []bundleFile
+	err := filepath.WalkDir(cfg.OutputPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		file := bundleFile{path: path}
+		file.size, file.sha256, file.err = checksum(path)
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamSpillsBigFiles(t *testing.T) {
+	cfg := &config.Config{OutputPath: filepath.Join(t.TempDir(), "steadybit-debug-1"), ArchiveFormat: ArchiveFormatTarGz}
+	if err := startStream(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	small := []byte("small file\n")
+	big := bytes.Repeat([]byte("0123456789abcdef"), spillThreshold/16+1024)
+	contents := map[string][]byte{"small.txt": small, "pods/big.txt": big}
+	for name, content := range contents {
+		w, err := CreateFile(filepath.Join(cfg.OutputPath, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// several writes cross the threshold in the middle of the content
+		for start := 0; start < len(content); start += 1 << 20 {
+			if _, err = w.Write(content[start:min(start+1<<20, len(content))]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if entry := w.(*streamEntry); (entry.spill != nil) != (len(content) > spillThreshold) {
+			t.Errorf("%s: spilled %t with %d bytes", name, entry.spill != nil, len(content))
+		}
+		spill := w.(*streamEntry).spill
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if spill != nil {
+			if _, err = os.Stat(spill.Name()); !os.IsNotExist(err) {
+				t.Errorf("%s: temporary file %s was not removed", name, spill.Name())
+			}
+		}
+	}
+
+	files, err := bundleFiles(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		name, _ := filepath.Rel(cfg.OutputPath, file.path)
+		checksum := sha256.Sum256(contents[filepath.ToSlash(name)])
+		if file.sha256 != hex.EncodeToString(checksum[:]) || file.size != int64(len(contents[filepath.ToSlash(name)])) {
+			t.Errorf("%s: got size %d and checksum %s", name, file.size, file.sha256)
+		}
+	}
+
+	archivePath, err := finishStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	found := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		name, _ := filepath.Rel("steadybit-debug-1", header.Name)
+		want, ok := contents[name]
+		if !ok {
+			continue
+		}
+		found++
+		got, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: archived content differs, got %d bytes, want %d", name, len(got), len(want))
+		}
+	}
+	if found != len(contents) {
+		t.Errorf("found %d of %d files in the archive", found, len(contents))
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UploadChecksumHeader carries the hex encoded SHA-256 checksum of the uploaded archive
+const UploadChecksumHeader = "X-Checksum-Sha256"
+
+// uploadInitialBackoff is the delay before the first retry, it doubles with every further one
+var uploadInitialBackoff = time.Second
+
+// ValidateUpload checks the upload configuration before anything gets collected
+func ValidateUpload(cfg config.UploadConfig) error {
+	if cfg.Url == "" {
+		return nil
+	}
+	u, err := url.Parse(cfg.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid upload URL '%s', expected an http or https URL", RedactUrl(cfg.Url))
+	}
+	_, err = uploadHeaders(cfg)
+	return err
+}
+
+// UploadArchive uploads the archive at path to cfg.Upload.Url, retrying network errors and server-side failures
+func UploadArchive(ctx context.Context, cfg *config.Config, path string) error {
+	_, sum, err := checksum(path)
+	if err != nil {
+		return err
+	}
+	target := RedactUrl(cfg.Upload.Url)
+
+	backoff := uploadInitialBackoff
+	for attempt := 0; ; attempt++ {
+		log.Info().Msgf("Uploading %s to %s", path, target)
+		retry, err := upload(ctx, cfg.Upload, path, sum)
+		if err == nil {
+			log.Info().Msgf("Uploaded %s to %s (sha256 %s)", path, target, sum)
+			return nil
+		}
+		if !retry || attempt >= cfg.Upload.Retries {
+			log.Error().Err(err).Msgf("Failed to upload %s to %s after %d attempts", path, target, attempt+1)
+			return err
+		}
+		log.Warn().Err(err).Msgf("Upload attempt %d failed, retrying in %s", attempt+1, backoff)
+		if !sleep(ctx, backoff) {
+			return context.Cause(ctx)
+		}
+		backoff *= 2
+	}
+}
+
+// upload sends the archive once and reports whether a failure is worth retrying
+func upload(ctx context.Context, cfg config.UploadConfig, path string, sum string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	headers, err := uploadHeaders(cfg)
+	if err != nil {
+		return false, err
+	}
+	var body io.Reader = file
+	size := info.Size()
+	if cfg.Method == http.MethodPost {
+		var contentType string
+		body, size, contentType = multipartBody(cfg.FormField, filepath.Base(path), file, size)
+		headers.Set("Content-Type", contentType)
+	} else if headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", "application/octet-stream")
+	}
+	headers.Set(UploadChecksumHeader, sum)
+
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+	request, err := http.NewRequestWithContext(ctx, method, cfg.Url, body)
+	if err != nil {
+		return false, err
+	}
+	// a known length avoids chunked transfer encoding, which presigned URLs don't accept
+	request.ContentLength = size
+	request.Header = headers
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, errors.New(RedactUrl(err.Error()))
+	}
+	defer response.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("upload failed with status %s: %s", response.Status, strings.TrimSpace(string(responseBody)))
+	return response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests, err
+}
+
+// multipartBody wraps the file into a multipart form with a single file field, without buffering the file
+func multipartBody(field string, fileName string, file io.Reader, size int64) (io.Reader, int64, string) {
+	var head bytes.Buffer
+	w := multipart.NewWriter(&head)
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, field, fileName))
+	partHeader.Set("Content-Type", "application/octet-stream")
+	w.CreatePart(partHeader)
+	headSize := int64(head.Len())
+
+	var tail bytes.Buffer
+	tail.WriteString(fmt.Sprintf("\r\n--%s--\r\n", w.Boundary()))
+	return io.MultiReader(&head, file, &tail), headSize + size + int64(tail.Len()), w.FormDataContentType()
+}
+
+func uploadHeaders(cfg config.UploadConfig) (http.Header, error) {
+	headers := http.Header{}
+	for _, header := range cfg.Headers {
+		name, value, found := strings.Cut(header, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			// the header may be a secret, so it is not part of the error
+			return nil, errors.New("invalid upload header, expected 'Name: value'")
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// RedactUrl removes query parameters, which carry the signature of presigned URLs, from all URLs in text
+func RedactUrl(text string) string {
+	fields := strings.Fields(text)
+	for _, field := range fields {
+		trimmed := strings.Trim(field, `"':,`)
+		if u, err := url.Parse(trimmed); err == nil && u.Host != "" && (u.RawQuery != "" || u.User != nil) {
+			u.RawQuery = ""
+			u.User = nil
+			text = strings.ReplaceAll(text, trimmed, u.String())
+		}
+	}
+	return text
+}
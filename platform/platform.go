@@ -4,27 +4,42 @@
 package platform
 
 import (
+	"context"
+	"fmt"
 	"github.com/rs/zerolog/log"
+	"github.com/steadybit/steadybit-debug/collector"
 	"github.com/steadybit/steadybit-debug/config"
 	"github.com/steadybit/steadybit-debug/k8s"
+	"github.com/steadybit/steadybit-debug/threaddump"
 	v1 "k8s.io/api/core/v1"
 	"path/filepath"
 	"sync"
 	"time"
 )
 
-func AddPlatformDebuggingInformation(cfg *config.Config) {
-	deployment, err := k8s.FindDeployment(cfg, cfg.Platform.Namespace, cfg.Platform.Deployment)
+func init() {
+	collector.Register(collector.WithPermissions(collector.New("platform", "Steadybit platform deployment, pods, logs, actuator endpoints and optionally the database export", nil, func(ctx context.Context, sink collector.Sink) error {
+		return AddPlatformDebuggingInformation(ctx, sink.Config())
+	}), platformPermissions))
+}
+
+func platformPermissions(cfg *config.Config) []collector.Permission {
+	result := k8s.WorkloadPermissions(cfg.Platform.Namespace, "deployments")
+	result = append(result, k8s.PodPermissions(cfg.Platform.Namespace)...)
+	return append(result, k8s.PortForwardPermission(cfg.Platform.Namespace))
+}
+
+func AddPlatformDebuggingInformation(ctx context.Context, cfg *config.Config) error {
+	deployment, err := k8s.FindDeployment(ctx, cfg, cfg.Platform.Namespace, cfg.Platform.Deployment)
 	if err != nil {
-		log.Warn().Msgf("Failed to find platform deployment '%s' in '%s': %s", cfg.Platform.Deployment, cfg.Platform.Namespace, err)
-		return
+		return fmt.Errorf("failed to find platform deployment '%s' in '%s': %w", cfg.Platform.Deployment, cfg.Platform.Namespace, err)
 	}
 
 	pathForPlatform := filepath.Join(cfg.OutputPath, "platform")
-	k8s.AddDescription(cfg, filepath.Join(pathForPlatform, "description.txt"), "deployment", deployment.Namespace, deployment.Name)
-	k8s.AddConfig(cfg, filepath.Join(pathForPlatform, "config.yaml"), "deployment", deployment.Namespace, deployment.Name)
+	k8s.AddDescription(ctx, cfg, filepath.Join(pathForPlatform, "description.txt"), "deployment", deployment.Namespace, deployment.Name)
+	k8s.AddConfig(ctx, cfg, filepath.Join(pathForPlatform, "config.yaml"), "deployment", deployment.Namespace, deployment.Name)
 
-	k8s.ForEachPod(cfg, deployment.Namespace, deployment.Spec.Selector, func(pod *v1.Pod, idx int) {
+	k8s.ForEachPod(ctx, cfg, deployment.Namespace, deployment.Spec.Selector, func(pod *v1.Pod, idx int) {
 		pathForPod := filepath.Join(pathForPlatform, "pods", pod.Name)
 		var wg sync.WaitGroup
 		if idx == 0 && cfg.Platform.ExportDatabase {
@@ -33,7 +48,7 @@ func AddPlatformDebuggingInformation(cfg *config.Config) {
 				log.Debug().Msgf("Downloading database export for platform %s", pod.Name)
 				defer wg.Done()
 				//Download Database export
-				k8s.DownloadFromPod(k8s.AddDownloadOutputOptions{
+				k8s.DownloadFromPod(ctx, k8s.AddDownloadOutputOptions{
 					PodNamespace: pod.Namespace,
 					PodName:      pod.Name,
 					Config:       cfg,
@@ -46,14 +61,15 @@ func AddPlatformDebuggingInformation(cfg *config.Config) {
 		}
 
 		delay := time.Millisecond * 500
+		threadDumpInterval := cfg.ThreadDumps.Interval.Duration()
 
-		k8s.AddDescription(cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
-		k8s.AddConfig(cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
-		k8s.AddLogs(cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
-		k8s.AddPreviousLogs(cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
-		k8s.AddResourceUsage(cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 10)
+		k8s.AddDescription(ctx, cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
+		k8s.AddConfig(ctx, cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
+		k8s.AddLogs(ctx, cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
+		k8s.AddPreviousLogs(ctx, cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
+		k8s.AddResourceUsage(ctx, cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 10)
 
-		k8s.AddPodHttpMultipleEndpointOutput(
+		k8s.AddPodHttpMultipleEndpointOutput(ctx,
 			k8s.AddPodHttpEndpointsOutputOptions{
 				SharedPort: 9090,
 				PodConfig: k8s.PodConfig{
@@ -80,8 +96,10 @@ func AddPlatformDebuggingInformation(cfg *config.Config) {
 						DelayBetweenExecutions: &delay,
 					},
 					{
-						OutputPath: filepath.Join(pathForPod, "threaddump.yml"),
-						Url:        "http://localhost:9090/actuator/threaddump",
+						OutputPath:             filepath.Join(pathForPod, threaddump.FileName(cfg.ThreadDumps.Count)),
+						Url:                    "http://localhost:9090/actuator/threaddump",
+						Executions:             cfg.ThreadDumps.Count,
+						DelayBetweenExecutions: &threadDumpInterval,
 					}, {
 						OutputPath: filepath.Join(pathForPod, "info.yml"),
 						Url:        "http://localhost:9090/actuator/info",
@@ -93,4 +111,5 @@ func AddPlatformDebuggingInformation(cfg *config.Config) {
 			})
 		wg.Wait()
 	})
+	return nil
 }
@@ -4,30 +4,43 @@
 package platform
 
 import (
-	"github.com/rs/zerolog/log"
+	"context"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/collector"
 	"github.com/steadybit/steadybit-debug/config"
 	"github.com/steadybit/steadybit-debug/k8s"
 	v1 "k8s.io/api/core/v1"
 	"path/filepath"
 )
 
-func AddPlatformPortSplitterDebuggingInformation(cfg *config.Config) {
-	deployment, err := k8s.FindDeployment(cfg, cfg.PlatformPortSplitter.Namespace, cfg.PlatformPortSplitter.Deployment)
+func init() {
+	collector.Register(collector.WithPermissions(collector.New("platform-port-splitter", "Steadybit platform port splitter deployment, pods and logs", nil, func(ctx context.Context, sink collector.Sink) error {
+		return AddPlatformPortSplitterDebuggingInformation(ctx, sink.Config())
+	}), platformPortSplitterPermissions))
+}
+
+func platformPortSplitterPermissions(cfg *config.Config) []collector.Permission {
+	result := k8s.WorkloadPermissions(cfg.PlatformPortSplitter.Namespace, "deployments")
+	return append(result, k8s.PodPermissions(cfg.PlatformPortSplitter.Namespace)...)
+}
+
+func AddPlatformPortSplitterDebuggingInformation(ctx context.Context, cfg *config.Config) error {
+	deployment, err := k8s.FindDeployment(ctx, cfg, cfg.PlatformPortSplitter.Namespace, cfg.PlatformPortSplitter.Deployment)
 	if err != nil {
-		log.Warn().Msgf("Failed to find platform port splitter deployment '%s' in '%s': %s", cfg.PlatformPortSplitter.Deployment, cfg.PlatformPortSplitter.Namespace, err)
-		return
+		return fmt.Errorf("failed to find platform port splitter deployment '%s' in '%s': %w", cfg.PlatformPortSplitter.Deployment, cfg.PlatformPortSplitter.Namespace, err)
 	}
 
 	pathForPlatformPortSplitter := filepath.Join(cfg.OutputPath, "platform-port-splitter")
-	k8s.AddDescription(cfg, filepath.Join(pathForPlatformPortSplitter, "description.txt"), "deployment", deployment.Namespace, deployment.Name)
-	k8s.AddConfig(cfg, filepath.Join(pathForPlatformPortSplitter, "config.yaml"), "deployment", deployment.Namespace, deployment.Name)
+	k8s.AddDescription(ctx, cfg, filepath.Join(pathForPlatformPortSplitter, "description.txt"), "deployment", deployment.Namespace, deployment.Name)
+	k8s.AddConfig(ctx, cfg, filepath.Join(pathForPlatformPortSplitter, "config.yaml"), "deployment", deployment.Namespace, deployment.Name)
 
-	k8s.ForEachPod(cfg, deployment.Namespace, deployment.Spec.Selector, func(pod *v1.Pod, idx int) {
+	k8s.ForEachPod(ctx, cfg, deployment.Namespace, deployment.Spec.Selector, func(pod *v1.Pod, idx int) {
 		pathForPod := filepath.Join(pathForPlatformPortSplitter, "pods", pod.Name)
-		k8s.AddDescription(cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
-		k8s.AddConfig(cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
-		k8s.AddLogs(cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
-		k8s.AddPreviousLogs(cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
-		k8s.AddResourceUsage(cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 5)
+		k8s.AddDescription(ctx, cfg, filepath.Join(pathForPod, "description.txt"), "pod", pod.Namespace, pod.Name)
+		k8s.AddConfig(ctx, cfg, filepath.Join(pathForPod, "config.yml"), "pod", pod.Namespace, pod.Name)
+		k8s.AddLogs(ctx, cfg, filepath.Join(pathForPod, "logs.txt"), pod.Namespace, pod.Name)
+		k8s.AddPreviousLogs(ctx, cfg, filepath.Join(pathForPod, "logs_previous.txt"), pod.Namespace, pod.Name)
+		k8s.AddResourceUsage(ctx, cfg, filepath.Join(pathForPod, "top.%d.txt"), pod.Namespace, pod.Name, 5)
 	})
+	return nil
 }
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/collector"
+	"github.com/steadybit/steadybit-debug/config"
+	"io"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Check is the outcome of a SelfSubjectAccessReview for a permission of a collector
+type Check struct {
+	Collector  string               `json:"collector"`
+	Permission collector.Permission `json:"permission"`
+	Allowed    bool                 `json:"allowed"`
+	Reason     string               `json:"reason,omitempty"`
+}
+
+// Result lists all checks and the collectors that lack required permissions
+type Result struct {
+	Checks []Check `json:"checks"`
+	// Missing maps the names of collectors to the required permissions they lack
+	Missing map[string][]collector.Permission `json:"missing"`
+}
+
+// Run checks all permissions declared by the collectors via SelfSubjectAccessReview. Permissions shared by several
+// collectors are checked once.
+func Run(ctx context.Context, cfg *config.Config, collectors []collector.Collector) (Result, error) {
+	client, err := cfg.Kubernetes.Client()
+	if err != nil {
+		return Result{}, err
+	}
+
+	type review struct {
+		allowed bool
+		reason  string
+		err     error
+	}
+	reviews := map[collector.Permission]*review{}
+	for _, c := range collectors {
+		for _, permission := range collector.Permissions(c, cfg) {
+			permission.Optional = false
+			reviews[permission] = &review{}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for permission, r := range reviews {
+		wg.Add(1)
+		go func(permission collector.Permission, r *review) {
+			defer wg.Done()
+			// concurrency is bounded by the shared Kubernetes client
+			response, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace:   permission.Namespace,
+						Verb:        permission.Verb,
+						Group:       permission.Group,
+						Resource:    permission.Resource,
+						Subresource: permission.Subresource,
+					},
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				r.err = err
+				return
+			}
+			r.allowed = response.Status.Allowed
+			r.reason = response.Status.Reason
+			if response.Status.EvaluationError != "" {
+				r.reason = strings.TrimSpace(r.reason + " " + response.Status.EvaluationError)
+			}
+		}(permission, r)
+	}
+	wg.Wait()
+
+	result := Result{Checks: []Check{}, Missing: map[string][]collector.Permission{}}
+	for _, c := range collectors {
+		for _, permission := range collector.Permissions(c, cfg) {
+			key := permission
+			key.Optional = false
+			r := reviews[key]
+			if r.err != nil {
+				return Result{}, fmt.Errorf("failed to review permission '%s': %w", permission, r.err)
+			}
+			result.Checks = append(result.Checks, Check{Collector: c.Name(), Permission: permission, Allowed: r.allowed, Reason: r.reason})
+			if !r.allowed && !permission.Optional {
+				result.Missing[c.Name()] = append(result.Missing[c.Name()], permission)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Gaps returns the checks that were denied
+func (r Result) Gaps() []Check {
+	var gaps []Check
+	for _, check := range r.Checks {
+		if !check.Allowed {
+			gaps = append(gaps, check)
+		}
+	}
+	return gaps
+}
+
+// Apply replaces the collectors lacking required permissions by disabled ones, so that they show up as failed in the
+// summary without running
+func (r Result) Apply(collectors []collector.Collector) []collector.Collector {
+	result := make([]collector.Collector, 0, len(collectors))
+	for _, c := range collectors {
+		if missing := r.Missing[c.Name()]; len(missing) > 0 {
+			names := make([]string, len(missing))
+			for i, permission := range missing {
+				names[i] = permission.String()
+			}
+			c = collector.Disable(c, fmt.Errorf("disabled by preflight, missing permissions: %s", strings.Join(names, ", ")))
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// WriteReport writes a table of all denied permissions per collector and whether the collector gets disabled
+func (r Result) WriteReport(w io.Writer) error {
+	gaps := r.Gaps()
+	if len(gaps) == 0 {
+		_, err := fmt.Fprintln(w, "Preflight: all required and optional permissions are granted")
+		return err
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].Collector < gaps[j].Collector
+	})
+
+	fmt.Fprintln(w, "Preflight: missing permissions")
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTOR\tPERMISSION\tEFFECT")
+	for _, gap := range gaps {
+		effect := "collector disabled"
+		if gap.Permission.Optional {
+			effect = "less information"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", gap.Collector, gap.Permission, effect)
+	}
+	return tw.Flush()
+}
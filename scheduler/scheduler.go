@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Resource is a kind of work whose concurrency is bounded across the whole run
+type Resource int
+
+const (
+	// Api are requests against the Kubernetes API server
+	Api Resource = iota
+	// Process are subprocesses like kubectl or curl
+	Process
+	// Http are HTTP requests to the platform, agent and extensions
+	Http
+)
+
+const DefaultParallelism = 10
+
+func (r Resource) String() string {
+	switch r {
+	case Api:
+		return "api"
+	case Process:
+		return "process"
+	case Http:
+		return "http"
+	default:
+		return fmt.Sprintf("resource(%d)", int(r))
+	}
+}
+
+var (
+	mu       sync.RWMutex
+	limiters = map[Resource]chan struct{}{
+		Api:     make(chan struct{}, DefaultParallelism),
+		Process: make(chan struct{}, DefaultParallelism),
+		Http:    make(chan struct{}, DefaultParallelism),
+	}
+)
+
+// Configure sets the maximum concurrency per resource. Values below 1 keep the default.
+// It must be called before any work is scheduled.
+func Configure(limits map[Resource]int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for resource, limit := range limits {
+		if limit < 1 {
+			limit = DefaultParallelism
+		}
+		limiters[resource] = make(chan struct{}, limit)
+	}
+}
+
+// Acquire blocks until a slot for the resource is available or the context is done.
+// The returned function releases the slot and must be called exactly once.
+func Acquire(ctx context.Context, resource Resource) (func(), error) {
+	mu.RLock()
+	limiter := limiters[resource]
+	mu.RUnlock()
+
+	select {
+	case limiter <- struct{}{}:
+		return func() { <-limiter }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Do runs fn while holding a slot for the resource
+func Do(ctx context.Context, resource Resource, fn func() error) error {
+	release, err := Acquire(ctx, resource)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package threaddump
+
+import (
+	"errors"
+	"fmt"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SourceFiles are the thread dumps of a bundle, a single one is stored as threaddump.yml, several as threaddump.N.yml
+var SourceFiles = []string{
+	"platform/pods/*/threaddump.yml",
+	"platform/pods/*/threaddump.*.yml",
+	"agent/pods/*/threaddump.yml",
+	"agent/pods/*/threaddump.*.yml",
+}
+
+// DigestFile is the name of the digest stored next to the thread dumps
+const DigestFile = "threaddump_digest.txt"
+
+var dumpNumberPattern = regexp.MustCompile(`threaddump\.(\d+)\.yml$`)
+
+// FileName returns the file name of the thread dumps, with a %d for the execution number if there are several
+func FileName(count int) string {
+	if count > 1 {
+		return "threaddump.%d.yml"
+	}
+	return "threaddump.yml"
+}
+
+// Digests returns the digests of the thread dumps of each pod of the bundle, ordered by directory
+func Digests(b *bundle.Bundle) []Digest {
+	byDir := map[string][]string{}
+	for _, pattern := range SourceFiles {
+		for _, p := range b.Glob(pattern) {
+			byDir[path.Dir(p)] = append(byDir[path.Dir(p)], p)
+		}
+	}
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	result := make([]Digest, 0, len(dirs))
+	for _, dir := range dirs {
+		files := byDir[dir]
+		sort.Slice(files, func(i, j int) bool {
+			return dumpNumber(files[i]) < dumpNumber(files[j])
+		})
+		var dumps []Dump
+		var failures []string
+		for _, file := range files {
+			dump, err := load(b, file)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", path.Base(file), err))
+				continue
+			}
+			dumps = append(dumps, dump)
+		}
+		digest := NewDigest(dir, dumps)
+		digest.Errors = failures
+		result = append(result, digest)
+	}
+	return result
+}
+
+func dumpNumber(file string) int {
+	if m := dumpNumberPattern.FindStringSubmatch(file); m != nil {
+		number, _ := strconv.Atoi(m[1])
+		return number
+	}
+	return -1
+}
+
+func load(b *bundle.Bundle, file string) (Dump, error) {
+	artifact, ok := b.Artifact(file)
+	if !ok {
+		return Dump{}, errors.New("not found")
+	}
+	if artifact.Error() != "" {
+		return Dump{}, fmt.Errorf("not collected: %s", artifact.Error())
+	}
+	threads, err := Parse([]byte(artifact.Body))
+	if err != nil {
+		return Dump{}, err
+	}
+	dump := Dump{Path: file, Threads: threads}
+	if started, err := time.Parse(time.RFC3339, artifact.Header["Started at"]); err == nil {
+		dump.Time = started
+	}
+	return dump, nil
+}
@@ -0,0 +1,361 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package threaddump
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// States are the thread states in the order they are reported
+var States = []string{"RUNNABLE", "BLOCKED", "WAITING", "TIMED_WAITING", "NEW", "TERMINATED"}
+
+// Digest condenses the thread dumps of a pod. All but Stuck and ThreadsPerDump describe the last dump.
+type Digest struct {
+	// Dir is the directory of the dumps relative to the bundle root, e.g. 'agent/pods/steadybit-agent-0'
+	Dir            string         `json:"dir"`
+	Dumps          []string       `json:"dumps"`
+	Span           time.Duration  `json:"span"`
+	ThreadsPerDump []int          `json:"threadsPerDump"`
+	Threads        int            `json:"threads"`
+	States         map[string]int `json:"states"`
+	Deadlocks      [][]LockWait   `json:"deadlocks"`
+	Contention     []Contention   `json:"contention"`
+	StackGroups    []StackGroup   `json:"stackGroups"`
+	Pools          []Pool         `json:"pools"`
+	Stuck          []StuckThread  `json:"stuck"`
+	// Errors are the dumps that could not be parsed
+	Errors []string `json:"errors,omitempty"`
+}
+
+// LockWait is a thread waiting for a lock held by Owner
+type LockWait struct {
+	Thread string `json:"thread"`
+	Lock   string `json:"lock"`
+	Owner  string `json:"owner"`
+}
+
+// Contention is a thread holding a lock other threads wait for. Chain lists the owners of the locks Owner waits for
+// itself, up to the thread at the root of the chain.
+type Contention struct {
+	Owner      string   `json:"owner"`
+	OwnerState string   `json:"ownerState"`
+	Lock       string   `json:"lock"`
+	Waiters    []string `json:"waiters"`
+	Chain      []string `json:"chain,omitempty"`
+}
+
+// StackGroup are threads with the same state and stack
+type StackGroup struct {
+	State   string   `json:"state"`
+	Threads []string `json:"threads"`
+	Stack   []string `json:"stack"`
+}
+
+// Pool are threads whose names only differ in numbers, e.g. 'http-nio-8080-exec-1' and 'http-nio-8080-exec-2'
+type Pool struct {
+	Name     string         `json:"name"`
+	Category string         `json:"category,omitempty"`
+	Threads  int            `json:"threads"`
+	States   map[string]int `json:"states"`
+	// Busy are the threads running or waiting for a lock, RUNNABLE threads waiting for I/O don't count
+	Busy int    `json:"busy"`
+	Hot  string `json:"hot,omitempty"`
+}
+
+// StuckThread is in the same state with the same stack in every dump while not being idle
+type StuckThread struct {
+	Thread string   `json:"thread"`
+	State  string   `json:"state"`
+	Lock   string   `json:"lock,omitempty"`
+	Stack  []string `json:"stack"`
+}
+
+const (
+	// hotPoolSize is the number of threads that makes a pool hot regardless of their state, e.g. leaked clients
+	hotPoolSize = 50
+	// minBusyThreads is the number of busy threads a pool needs to be hot because of them
+	minBusyThreads = 2
+)
+
+var (
+	numberPattern = regexp.MustCompile(`\d+`)
+	categories    = []struct {
+		name    string
+		threads *regexp.Regexp
+		classes *regexp.Regexp
+	}{
+		{"websocket", regexp.MustCompile(`(?i)websocket|\bws-`), regexp.MustCompile(`(?i)websocket`)},
+		{"discovery", regexp.MustCompile(`(?i)discover`), regexp.MustCompile(`(?i)\.discovery\.`)},
+		{"http-client", regexp.MustCompile(`(?i)http-?client|okhttp|reactor-http|I/O dispatcher|hc5`),
+			regexp.MustCompile(`^(jdk\.internal\.net\.http|java\.net\.http|org\.apache\.hc|org\.apache\.http\.impl\.nio|okhttp3|reactor\.netty\.http\.client|org\.eclipse\.jetty\.client)\.`)},
+		{"http-server", regexp.MustCompile(`(?i)http-nio|tomcat|jetty|undertow|XNIO`), nil},
+	}
+)
+
+// NewDigest condenses the dumps of a pod, which must be ordered by time
+func NewDigest(dir string, dumps []Dump) Digest {
+	digest := Digest{Dir: dir, States: map[string]int{}}
+	if len(dumps) == 0 {
+		return digest
+	}
+	for _, dump := range dumps {
+		digest.Dumps = append(digest.Dumps, dump.Path)
+		digest.ThreadsPerDump = append(digest.ThreadsPerDump, len(dump.Threads))
+	}
+	first, last := dumps[0], dumps[len(dumps)-1]
+	if !first.Time.IsZero() && !last.Time.IsZero() {
+		digest.Span = last.Time.Sub(first.Time)
+	}
+
+	threads := last.Threads
+	digest.Threads = len(threads)
+	for _, thread := range threads {
+		digest.States[thread.ThreadState]++
+	}
+	deadlocked := map[int64]bool{}
+	digest.Deadlocks = deadlocks(threads, deadlocked)
+	digest.Contention = contention(threads, deadlocked)
+	digest.StackGroups = stackGroups(threads)
+	digest.Pools = pools(threads)
+	if len(dumps) > 1 {
+		digest.Stuck = stuck(dumps)
+	}
+	return digest
+}
+
+func byId(threads []Thread) map[int64]Thread {
+	result := make(map[int64]Thread, len(threads))
+	for _, thread := range threads {
+		result[thread.ThreadId] = thread
+	}
+	return result
+}
+
+// deadlocks returns the cycles of threads waiting for locks held by each other and marks their threads in deadlocked
+func deadlocks(threads []Thread, deadlocked map[int64]bool) [][]LockWait {
+	ids := byId(threads)
+	var result [][]LockWait
+	for _, start := range threads {
+		if deadlocked[start.ThreadId] {
+			continue
+		}
+		// follow the owners, a cycle not containing start is found when starting from one of its threads
+		seen := map[int64]bool{}
+		cyclic := false
+		for current := start; !cyclic; {
+			if _, owned := current.waitsFor(); !owned {
+				break
+			}
+			seen[current.ThreadId] = true
+			next, ok := ids[current.LockOwnerId]
+			if !ok || (seen[next.ThreadId] && next.ThreadId != start.ThreadId) {
+				break
+			}
+			cyclic = next.ThreadId == start.ThreadId
+			current = next
+		}
+		if !cyclic {
+			continue
+		}
+		var cycle []LockWait
+		for thread := start; ; thread = ids[thread.LockOwnerId] {
+			lock, _ := thread.waitsFor()
+			deadlocked[thread.ThreadId] = true
+			cycle = append(cycle, LockWait{Thread: thread.ThreadName, Lock: lock, Owner: thread.LockOwnerName})
+			if thread.LockOwnerId == start.ThreadId {
+				break
+			}
+		}
+		result = append(result, cycle)
+	}
+	return result
+}
+
+// contention returns the lock owners other threads wait for, except deadlocked ones, most waiters first
+func contention(threads []Thread, deadlocked map[int64]bool) []Contention {
+	ids := byId(threads)
+	type key struct {
+		owner int64
+		lock  string
+	}
+	waiters := map[key][]string{}
+	var keys []key
+	for _, thread := range threads {
+		lock, owned := thread.waitsFor()
+		if !owned || deadlocked[thread.ThreadId] {
+			continue
+		}
+		k := key{owner: thread.LockOwnerId, lock: lock}
+		if _, ok := waiters[k]; !ok {
+			keys = append(keys, k)
+		}
+		waiters[k] = append(waiters[k], thread.ThreadName)
+	}
+
+	result := make([]Contention, 0, len(keys))
+	for _, k := range keys {
+		owner, ok := ids[k.owner]
+		c := Contention{Owner: owner.ThreadName, OwnerState: owner.ThreadState, Lock: k.lock, Waiters: waiters[k]}
+		if !ok {
+			c.Owner, c.OwnerState = fmt.Sprintf("thread %d", k.owner), "unknown"
+		}
+		seen := map[int64]bool{k.owner: true}
+		for ok {
+			if _, owned := owner.waitsFor(); !owned || seen[owner.LockOwnerId] {
+				break
+			}
+			seen[owner.LockOwnerId] = true
+			c.Chain = append(c.Chain, owner.LockOwnerName)
+			owner, ok = ids[owner.LockOwnerId]
+		}
+		sort.Strings(c.Waiters)
+		result = append(result, c)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if len(result[i].Waiters) != len(result[j].Waiters) {
+			return len(result[i].Waiters) > len(result[j].Waiters)
+		}
+		return result[i].Owner < result[j].Owner
+	})
+	return result
+}
+
+// stackGroups returns the groups of at least two threads with the same state and stack, biggest first
+func stackGroups(threads []Thread) []StackGroup {
+	groups := map[string]*StackGroup{}
+	var keys []string
+	for _, thread := range threads {
+		if len(thread.StackTrace) == 0 {
+			continue
+		}
+		stack := frames(thread)
+		k := thread.ThreadState + "\n" + strings.Join(stack, "\n")
+		group, ok := groups[k]
+		if !ok {
+			group = &StackGroup{State: thread.ThreadState, Stack: stack}
+			groups[k] = group
+			keys = append(keys, k)
+		}
+		group.Threads = append(group.Threads, thread.ThreadName)
+	}
+	var result []StackGroup
+	for _, k := range keys {
+		if group := groups[k]; len(group.Threads) > 1 {
+			sort.Strings(group.Threads)
+			result = append(result, *group)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].Threads) > len(result[j].Threads)
+	})
+	return result
+}
+
+func frames(thread Thread) []string {
+	result := make([]string, len(thread.StackTrace))
+	for i, frame := range thread.StackTrace {
+		result[i] = frame.String()
+	}
+	return result
+}
+
+// pools groups the threads by their name without numbers, hot ones first, then by size
+func pools(threads []Thread) []Pool {
+	byName := map[string]*Pool{}
+	var names []string
+	for _, thread := range threads {
+		name := numberPattern.ReplaceAllString(thread.ThreadName, "#")
+		pool, ok := byName[name]
+		if !ok {
+			pool = &Pool{Name: name, States: map[string]int{}}
+			byName[name] = pool
+			names = append(names, name)
+		}
+		pool.Threads++
+		pool.States[thread.ThreadState]++
+		if !thread.idle() {
+			pool.Busy++
+		}
+		if pool.Category == "" {
+			pool.Category = category(thread)
+		}
+	}
+
+	result := make([]Pool, 0, len(names))
+	for _, name := range names {
+		pool := byName[name]
+		switch {
+		case pool.Threads >= hotPoolSize:
+			pool.Hot = fmt.Sprintf("%d threads", pool.Threads)
+		case pool.Busy >= minBusyThreads && pool.Busy*2 >= pool.Threads:
+			pool.Hot = fmt.Sprintf("%d of %d threads busy", pool.Busy, pool.Threads)
+		}
+		result = append(result, *pool)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if (result[i].Hot != "") != (result[j].Hot != "") {
+			return result[i].Hot != ""
+		}
+		if result[i].Threads != result[j].Threads {
+			return result[i].Threads > result[j].Threads
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// category classifies a thread as websocket, discovery, HTTP client or HTTP server thread by its name or stack
+func category(thread Thread) string {
+	for _, c := range categories {
+		if c.threads.MatchString(thread.ThreadName) {
+			return c.name
+		}
+		if c.classes == nil {
+			continue
+		}
+		for _, frame := range thread.StackTrace {
+			if c.classes.MatchString(frame.ClassName) {
+				return c.name
+			}
+		}
+	}
+	return ""
+}
+
+// stuck returns the threads that are busy with the same state and stack in all dumps
+func stuck(dumps []Dump) []StuckThread {
+	later := make([]map[int64]Thread, len(dumps)-1)
+	for i, dump := range dumps[1:] {
+		later[i] = byId(dump.Threads)
+	}
+	var result []StuckThread
+	for _, thread := range dumps[0].Threads {
+		if thread.idle() || len(thread.StackTrace) == 0 {
+			continue
+		}
+		stack := strings.Join(frames(thread), "\n")
+		same := true
+		for _, threads := range later {
+			other, ok := threads[thread.ThreadId]
+			if !ok || other.ThreadName != thread.ThreadName || other.ThreadState != thread.ThreadState ||
+				strings.Join(frames(other), "\n") != stack {
+				same = false
+				break
+			}
+		}
+		if !same {
+			continue
+		}
+		lock, _ := thread.waitsFor()
+		result = append(result, StuckThread{Thread: thread.ThreadName, State: thread.ThreadState, Lock: lock, Stack: frames(thread)})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Thread < result[j].Thread
+	})
+	return result
+}
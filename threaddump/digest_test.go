@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package threaddump
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func loadDump(t *testing.T, file string) Dump {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	threads, err := Parse(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Dump{Path: file, Threads: threads}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		threads int
+		wantErr bool
+	}{
+		{name: "actuator object", content: `{"threads":[{"threadName":"main","threadId":1,"threadState":"RUNNABLE"}]}`, threads: 1},
+		{name: "array", content: ` [{"threadName":"main","threadId":1},{"threadName":"worker","threadId":2}]` + "\n", threads: 2},
+		{name: "empty", content: "\n", wantErr: true},
+		{name: "no threads", content: `{"threads":[]}`, wantErr: true},
+		{name: "error page", content: "<html><body>Whitelabel Error Page</body></html>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threads, err := Parse([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if len(threads) != tt.threads {
+				t.Errorf("got %d threads, want %d", len(threads), tt.threads)
+			}
+		})
+	}
+}
+
+func TestDeadlocks(t *testing.T) {
+	waiting := func(name string, id int64, lock string, ownerId int64, owner string) Thread {
+		return Thread{ThreadName: name, ThreadId: id, ThreadState: "BLOCKED", LockName: lock, LockOwnerId: ownerId, LockOwnerName: owner}
+	}
+	running := Thread{ThreadName: "c", ThreadId: 3, ThreadState: "RUNNABLE", LockOwnerId: -1}
+
+	tests := []struct {
+		name       string
+		threads    []Thread
+		want       [][]LockWait
+		deadlocked []int64
+	}{
+		{
+			name:    "actuator dump with two cycles",
+			threads: loadDump(t, "deadlock.json").Threads,
+			want: [][]LockWait{
+				{
+					{Thread: "pool-3-thread-1", Lock: "java.lang.Object@1b6d3586", Owner: "pool-3-thread-2"},
+					{Thread: "pool-3-thread-2", Lock: "java.lang.Object@5e9f23b4", Owner: "pool-3-thread-1"},
+				},
+				{
+					{Thread: "scheduler-1", Lock: "java.util.concurrent.locks.ReentrantLock$NonfairSync@4554617c", Owner: "scheduler-2"},
+					{Thread: "scheduler-2", Lock: "java.util.concurrent.locks.ReentrantLock$NonfairSync@74a14482", Owner: "scheduler-3"},
+					{Thread: "scheduler-3", Lock: "java.util.concurrent.locks.ReentrantLock$NonfairSync@1540e19d", Owner: "scheduler-1"},
+				},
+			},
+			deadlocked: []int64{41, 42, 51, 52, 53},
+		},
+		{
+			name:    "chain ending in a running thread",
+			threads: []Thread{waiting("a", 1, "L1", 2, "b"), waiting("b", 2, "L2", 3, "c"), running},
+		},
+		{
+			name:    "waiting for a cycle without being part of it",
+			threads: []Thread{waiting("x", 9, "L1", 1, "a"), waiting("a", 1, "L2", 2, "b"), waiting("b", 2, "L3", 1, "a")},
+			want: [][]LockWait{{
+				{Thread: "a", Lock: "L2", Owner: "b"},
+				{Thread: "b", Lock: "L3", Owner: "a"},
+			}},
+			deadlocked: []int64{1, 2},
+		},
+		{
+			name:    "owner missing from the dump",
+			threads: []Thread{waiting("a", 1, "L1", 7, "gone")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadlocked := map[int64]bool{}
+			got := deadlocks(tt.threads, deadlocked)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if len(deadlocked) != len(tt.deadlocked) {
+				t.Errorf("got deadlocked %v, want %v", deadlocked, tt.deadlocked)
+			}
+			for _, id := range tt.deadlocked {
+				if !deadlocked[id] {
+					t.Errorf("thread %d not marked as deadlocked", id)
+				}
+			}
+		})
+	}
+}
+
+func TestContentionSkipsDeadlockedThreads(t *testing.T) {
+	digest := NewDigest("platform/pods/steadybit-platform-0", []Dump{loadDump(t, "deadlock.json")})
+	want := []Contention{{
+		Owner:      "pool-3-thread-1",
+		OwnerState: "BLOCKED",
+		Lock:       "java.lang.Object@5e9f23b4",
+		Waiters:    []string{"http-nio-8080-exec-1", "http-nio-8080-exec-2"},
+		Chain:      []string{"pool-3-thread-2"},
+	}}
+	if !reflect.DeepEqual(digest.Contention, want) {
+		t.Errorf("got %+v, want %+v", digest.Contention, want)
+	}
+}
+
+func TestStuck(t *testing.T) {
+	first, second := loadDump(t, "stuck.1.json"), loadDump(t, "stuck.2.json")
+	first.Time = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	second.Time = first.Time.Add(5 * time.Second)
+
+	tests := []struct {
+		name  string
+		dumps []Dump
+		want  []string
+		locks []string
+	}{
+		{
+			name:  "busy threads with the same stack in all dumps",
+			dumps: []Dump{first, second},
+			want:  []string{"db-writer-1", "http-nio-8080-exec-1"},
+			locks: []string{"", "java.lang.Object@5e9f23b4"},
+		},
+		{
+			name:  "identical dumps leave out idle threads",
+			dumps: []Dump{first, first},
+			want:  []string{"db-writer-1", "http-nio-8080-exec-1", "http-nio-8080-exec-2", "report-1"},
+			locks: []string{"", "java.lang.Object@5e9f23b4", "java.lang.Object@5e9f23b4", ""},
+		},
+		{
+			name:  "single dump",
+			dumps: []Dump{first},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest := NewDigest("agent/pods/steadybit-agent-0", tt.dumps)
+			var threads, locks []string
+			for _, s := range digest.Stuck {
+				threads = append(threads, s.Thread)
+				locks = append(locks, s.Lock)
+			}
+			if !reflect.DeepEqual(threads, tt.want) || !reflect.DeepEqual(locks, tt.locks) {
+				t.Errorf("got stuck %v with locks %v, want %v with locks %v", threads, locks, tt.want, tt.locks)
+			}
+		})
+	}
+
+	digest := NewDigest("agent/pods/steadybit-agent-0", []Dump{first, second})
+	if digest.Span != 5*time.Second {
+		t.Errorf("got span %s", digest.Span)
+	}
+	if !reflect.DeepEqual(digest.ThreadsPerDump, []int{6, 5}) {
+		t.Errorf("got threads per dump %v", digest.ThreadsPerDump)
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+// Package threaddump digests the JSON thread dumps of the platform actuator and the agent
+package threaddump
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+type Frame struct {
+	ClassName    string `json:"className"`
+	MethodName   string `json:"methodName"`
+	FileName     string `json:"fileName"`
+	LineNumber   int    `json:"lineNumber"`
+	NativeMethod bool   `json:"nativeMethod"`
+}
+
+// String formats the frame like a Java stack trace, e.g. 'java.lang.Thread.sleep(Thread.java:340)'
+func (f Frame) String() string {
+	location := "Unknown Source"
+	switch {
+	case f.NativeMethod:
+		location = "Native Method"
+	case f.FileName != "" && f.LineNumber > 0:
+		location = fmt.Sprintf("%s:%d", f.FileName, f.LineNumber)
+	case f.FileName != "":
+		location = f.FileName
+	}
+	return fmt.Sprintf("%s.%s(%s)", f.ClassName, f.MethodName, location)
+}
+
+type Lock struct {
+	ClassName        string `json:"className"`
+	IdentityHashCode int64  `json:"identityHashCode"`
+}
+
+// String formats the lock like lockName of the JVM, e.g. 'java.lang.Object@1b6d3586'
+func (l Lock) String() string {
+	return fmt.Sprintf("%s@%x", l.ClassName, l.IdentityHashCode)
+}
+
+type Monitor struct {
+	Lock
+	LockedStackDepth int `json:"lockedStackDepth"`
+}
+
+// Thread is a java.lang.management.ThreadInfo as serialized by the Spring Boot actuator
+type Thread struct {
+	ThreadName          string    `json:"threadName"`
+	ThreadId            int64     `json:"threadId"`
+	ThreadState         string    `json:"threadState"`
+	Daemon              bool      `json:"daemon"`
+	LockName            string    `json:"lockName"`
+	LockOwnerId         int64     `json:"lockOwnerId"`
+	LockOwnerName       string    `json:"lockOwnerName"`
+	LockInfo            *Lock     `json:"lockInfo"`
+	StackTrace          []Frame   `json:"stackTrace"`
+	LockedMonitors      []Monitor `json:"lockedMonitors"`
+	LockedSynchronizers []Lock    `json:"lockedSynchronizers"`
+}
+
+// Dump is a parsed thread dump file
+type Dump struct {
+	Path string
+	// Time is the start of the request, zero if unknown
+	Time    time.Time
+	Threads []Thread
+}
+
+// waitsFor returns the lock the thread waits for and whether another thread owns it
+func (t Thread) waitsFor() (string, bool) {
+	lock := t.LockName
+	if lock == "" && t.LockInfo != nil {
+		lock = t.LockInfo.String()
+	}
+	return lock, lock != "" && t.LockOwnerId >= 0 && t.LockOwnerName != "" && t.ThreadState != "RUNNABLE"
+}
+
+// idleMethodPattern matches native methods threads wait in for I/O while being RUNNABLE, e.g. epollWait
+var idleMethodPattern = regexp.MustCompile(`(?i)epoll|poll|select|accept|read|kevent|wait`)
+
+// idle reports whether the thread waits for work or I/O instead of running or waiting for a lock held by another thread
+func (t Thread) idle() bool {
+	switch t.ThreadState {
+	case "RUNNABLE":
+		return len(t.StackTrace) > 0 && t.StackTrace[0].NativeMethod && idleMethodPattern.MatchString(t.StackTrace[0].MethodName)
+	case "BLOCKED":
+		return false
+	}
+	_, owned := t.waitsFor()
+	return !owned
+}
+
+// Parse reads a thread dump, either an object with a 'threads' array like the actuator returns or the array itself
+func Parse(content []byte) ([]Thread, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, errors.New("empty thread dump")
+	}
+	var threads []Thread
+	if content[0] == '[' {
+		if err := json.Unmarshal(content, &threads); err != nil {
+			return nil, fmt.Errorf("no JSON thread dump: %w", err)
+		}
+	} else {
+		var dump struct {
+			Threads []Thread `json:"threads"`
+		}
+		if err := json.Unmarshal(content, &dump); err != nil {
+			return nil, fmt.Errorf("no JSON thread dump: %w", err)
+		}
+		threads = dump.Threads
+	}
+	if len(threads) == 0 {
+		return nil, errors.New("the thread dump contains no threads")
+	}
+	return threads, nil
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package threaddump
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// maxStackGroups, maxPools and maxFrames keep the digest readable, the dumps hold the complete information
+	maxStackGroups = 10
+	maxPools       = 15
+	maxFrames      = 12
+	maxNames       = 5
+)
+
+// Write prints the digest as text
+func (d Digest) Write(w io.Writer) error {
+	fmt.Fprintf(w, "Thread dump digest of %s\n", d.Dir)
+	for _, e := range d.Errors {
+		fmt.Fprintf(w, "Skipped %s\n", e)
+	}
+	if len(d.Dumps) == 0 {
+		_, err := fmt.Fprintln(w, "No thread dump could be read")
+		return err
+	}
+	if len(d.Dumps) > 1 {
+		counts := make([]string, len(d.ThreadsPerDump))
+		for i, count := range d.ThreadsPerDump {
+			counts[i] = fmt.Sprint(count)
+		}
+		fmt.Fprintf(w, "%d dumps over %s, threads per dump: %s\n", len(d.Dumps), d.Span, strings.Join(counts, ", "))
+	}
+	fmt.Fprintf(w, "\nThreads of %s: %d (%s)\n", path.Base(d.Dumps[len(d.Dumps)-1]), d.Threads, d.states())
+
+	fmt.Fprintln(w, "\nDeadlocks:")
+	if len(d.Deadlocks) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	for i, cycle := range d.Deadlocks {
+		fmt.Fprintf(w, "  Deadlock %d:\n", i+1)
+		for _, wait := range cycle {
+			fmt.Fprintf(w, "    %q waits for %s held by %q\n", wait.Thread, wait.Lock, wait.Owner)
+		}
+	}
+
+	fmt.Fprintln(w, "\nLock contention:")
+	if len(d.Contention) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	for _, c := range d.Contention {
+		fmt.Fprintf(w, "  %q (%s) holds %s, %d waiting: %s\n", c.Owner, c.OwnerState, c.Lock, len(c.Waiters), names(c.Waiters))
+		if len(c.Chain) > 0 {
+			fmt.Fprintf(w, "    and waits itself: %s\n", strings.Join(quote(append([]string{c.Owner}, c.Chain...)), " -> "))
+		}
+	}
+
+	if len(d.Stuck) > 0 || len(d.Dumps) > 1 {
+		fmt.Fprintf(w, "\nStuck threads (same state and stack in all %d dumps):\n", len(d.Dumps))
+		if len(d.Stuck) == 0 {
+			fmt.Fprintln(w, "  none")
+		}
+		for _, s := range d.Stuck {
+			lock := ""
+			if s.Lock != "" {
+				lock = " on " + s.Lock
+			}
+			fmt.Fprintf(w, "  %q %s%s\n", s.Thread, s.State, lock)
+			writeStack(w, s.Stack)
+		}
+	}
+
+	fmt.Fprintln(w, "\nHot pools:")
+	hot := 0
+	for _, pool := range d.Pools {
+		if pool.Hot != "" {
+			hot++
+			fmt.Fprintf(w, "  %s %s: %s\n", orDash(pool.Category), pool.Name, pool.Hot)
+		}
+	}
+	if hot == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+
+	fmt.Fprintln(w, "\nPools:")
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "POOL\tCATEGORY\tTHREADS\tBUSY\tRUNNABLE\tBLOCKED\tWAITING\tTIMED_WAITING")
+	for i, pool := range d.Pools {
+		if i == maxPools {
+			fmt.Fprintf(tw, "... %d more\n", len(d.Pools)-maxPools)
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", pool.Name, orDash(pool.Category), pool.Threads, pool.Busy,
+			pool.States["RUNNABLE"], pool.States["BLOCKED"], pool.States["WAITING"], pool.States["TIMED_WAITING"])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nIdentical stacks:")
+	if len(d.StackGroups) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	for i, group := range d.StackGroups {
+		if i == maxStackGroups {
+			fmt.Fprintf(w, "  ... %d more groups\n", len(d.StackGroups)-maxStackGroups)
+			break
+		}
+		fmt.Fprintf(w, "  %d threads %s: %s\n", len(group.Threads), group.State, names(group.Threads))
+		writeStack(w, group.Stack)
+	}
+	return nil
+}
+
+func (d Digest) states() string {
+	var result []string
+	for _, state := range States {
+		if count := d.States[state]; count > 0 {
+			result = append(result, fmt.Sprintf("%s %d", state, count))
+		}
+	}
+	return strings.Join(result, ", ")
+}
+
+func writeStack(w io.Writer, stack []string) {
+	for i, frame := range stack {
+		if i == maxFrames {
+			fmt.Fprintf(w, "      ... %d more\n", len(stack)-maxFrames)
+			break
+		}
+		fmt.Fprintf(w, "      at %s\n", frame)
+	}
+}
+
+func names(threads []string) string {
+	if len(threads) <= maxNames {
+		return strings.Join(quote(threads), ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(quote(threads[:maxNames]), ", "), len(threads)-maxNames)
+}
+
+func quote(values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = fmt.Sprintf("%q", value)
+	}
+	return result
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
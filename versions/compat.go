@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package versions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sigs.k8s.io/yaml"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CompatibilityRule requires the components matching Requires to have certain versions when a component matching
+// Component is present, e.g. that extension-container >= 1.5 needs agent >= 2.1. Component names are matched via
+// path.Match against the full name, e.g. 'extension steadybit-agent/extension-container', and the name of extensions
+// without namespace, e.g. 'extension-container'.
+type CompatibilityRule struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Severity is 'warning' or 'error' (default)
+	Severity  string `json:"severity"`
+	Component string `json:"component"`
+	// Version restricts the rule to versions of Component matching the constraint, e.g. '>=1.5.0, <2'
+	Version string `json:"version"`
+	// Requires maps component name patterns to version constraints
+	Requires map[string]string `json:"requires"`
+}
+
+// Incompatibility is a component version that violates a compatibility rule
+type Incompatibility struct {
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	// Required is the component that doesn't match the constraint
+	Required        string `json:"required"`
+	RequiredVersion string `json:"requiredVersion"`
+	Constraint      string `json:"constraint"`
+	Message         string `json:"message"`
+}
+
+var compatibility = struct {
+	mu         sync.Mutex
+	configured bool
+	rules      []CompatibilityRule
+}{}
+
+// ConfigureCompatibility loads the rules incompatibilities are checked against, see config.Config.CompatFile
+func ConfigureCompatibility(file string) error {
+	var rules []CompatibilityRule
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var compatFile struct {
+			Rules []CompatibilityRule `json:"rules"`
+		}
+		if err = yaml.UnmarshalStrict(content, &compatFile); err != nil {
+			return fmt.Errorf("failed to parse compatibility rules '%s': %w", file, err)
+		}
+		for i, rule := range compatFile.Rules {
+			if err = validateRule(&rule); err != nil {
+				return fmt.Errorf("invalid compatibility rule %d in '%s': %w", i+1, file, err)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	compatibility.mu.Lock()
+	defer compatibility.mu.Unlock()
+	compatibility.configured = file != ""
+	compatibility.rules = rules
+	return nil
+}
+
+// CompatibilityConfigured reports whether rules were loaded, otherwise incompatibilities are not checked
+func CompatibilityConfigured() bool {
+	compatibility.mu.Lock()
+	defer compatibility.mu.Unlock()
+	return compatibility.configured
+}
+
+func validateRule(rule *CompatibilityRule) error {
+	if rule.Name == "" {
+		return errors.New("rule without name")
+	}
+	if rule.Component == "" || len(rule.Requires) == 0 {
+		return fmt.Errorf("rule '%s' needs a component and requirements", rule.Name)
+	}
+	switch rule.Severity {
+	case "":
+		rule.Severity = "error"
+	case "warning", "error":
+	default:
+		return fmt.Errorf("rule '%s' has unknown severity '%s', expected warning or error", rule.Name, rule.Severity)
+	}
+	patterns := []string{rule.Component}
+	constraints := []string{}
+	if rule.Version != "" {
+		constraints = append(constraints, rule.Version)
+	}
+	for pattern, c := range rule.Requires {
+		patterns = append(patterns, pattern)
+		constraints = append(constraints, c)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("rule '%s' has an invalid component pattern '%s': %w", rule.Name, pattern, err)
+		}
+	}
+	for _, c := range constraints {
+		if _, err := parseConstraint(c); err != nil {
+			return fmt.Errorf("rule '%s': %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkCompatibility returns the incompatibilities of the components. Components with an unknown or unparseable
+// version are not checked.
+func checkCompatibility(components []Component) []Incompatibility {
+	compatibility.mu.Lock()
+	rules := compatibility.rules
+	compatibility.mu.Unlock()
+
+	var result []Incompatibility
+	for _, rule := range rules {
+		when, _ := parseConstraint(rule.Version)
+		for _, component := range components {
+			if !matchesComponent(rule.Component, component) || !anyVersion(component.Version, when) {
+				continue
+			}
+			requirements := make([]string, 0, len(rule.Requires))
+			for pattern := range rule.Requires {
+				requirements = append(requirements, pattern)
+			}
+			sort.Strings(requirements)
+			for _, pattern := range requirements {
+				required, _ := parseConstraint(rule.Requires[pattern])
+				for _, other := range components {
+					if other.Name == component.Name || !matchesComponent(pattern, other) || other.Version == "" {
+						continue
+					}
+					for _, v := range strings.Split(other.Version, ", ") {
+						if parsed, ok := parseVersion(v); ok && !required.matches(parsed) {
+							result = append(result, Incompatibility{
+								Rule:            rule.Name,
+								Severity:        rule.Severity,
+								Component:       component.Name,
+								Version:         component.Version,
+								Required:        other.Name,
+								RequiredVersion: v,
+								Constraint:      rule.Requires[pattern],
+								Message: fmt.Sprintf("%s requires %s %s, found %s", strings.TrimSpace(component.shortName()+" "+component.Version),
+									other.shortName(), rule.Requires[pattern], v),
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+func matchesComponent(pattern string, component Component) bool {
+	if matched, _ := path.Match(pattern, component.Name); matched {
+		return true
+	}
+	matched, _ := path.Match(pattern, component.shortName())
+	return matched
+}
+
+// anyVersion reports whether one of the comma-separated versions matches the constraint. An empty constraint matches
+// all components, even those with unknown versions.
+func anyVersion(versions string, c constraint) bool {
+	if len(c) == 0 {
+		return true
+	}
+	for _, v := range strings.Split(versions, ", ") {
+		if parsed, ok := parseVersion(v); ok && c.matches(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package versions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a semantic version like 2.1.0 or v1.4.2-rc.1, build metadata is ignored
+type version struct {
+	parts      []int
+	prerelease string
+}
+
+func parseVersion(value string) (version, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	value, _, _ = strings.Cut(value, "+")
+	core, prerelease, _ := strings.Cut(value, "-")
+	if core == "" {
+		return version{}, false
+	}
+	v := version{prerelease: prerelease}
+	for _, part := range strings.Split(core, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return version{}, false
+		}
+		v.parts = append(v.parts, number)
+	}
+	return v, true
+}
+
+// compare returns -1, 0 or 1. Missing parts count as 0 and pre-releases precede the release.
+func (v version) compare(other version) int {
+	for i := 0; i < max(len(v.parts), len(other.parts)); i++ {
+		if c := compareInts(part(v.parts, i), part(other.parts, i)); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.prerelease, other.prerelease)
+}
+
+// comparePrerelease compares dot-separated identifiers, numeric ones numerically and before alphanumeric ones,
+// e.g. rc.2 < rc.10 < rc.a
+func comparePrerelease(a string, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < min(len(as), len(bs)); i++ {
+		x, xErr := strconv.Atoi(as[i])
+		y, yErr := strconv.Atoi(bs[i])
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				return compareInts(x, y)
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(as), len(bs))
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func part(parts []int, i int) int {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return 0
+}
+
+type comparison struct {
+	operator string
+	version  version
+}
+
+// constraint is a comma-separated list of comparisons that all must hold, e.g. '>=2.1.0, <3'
+type constraint []comparison
+
+func parseConstraint(value string) (constraint, error) {
+	var result constraint
+	for _, term := range strings.Split(value, ",") {
+		term = strings.TrimSpace(term)
+		operator := ""
+		for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(term, candidate) {
+				operator = candidate
+				break
+			}
+		}
+		v, ok := parseVersion(strings.TrimPrefix(term, operator))
+		if !ok {
+			return nil, fmt.Errorf("invalid version constraint '%s'", value)
+		}
+		if operator == "" {
+			operator = "="
+		}
+		result = append(result, comparison{operator: operator, version: v})
+	}
+	return result, nil
+}
+
+func (c constraint) matches(v version) bool {
+	for _, comparison := range c {
+		result := v.compare(comparison.version)
+		var ok bool
+		switch comparison.operator {
+		case ">=":
+			ok = result >= 0
+		case "<=":
+			ok = result <= 0
+		case ">":
+			ok = result > 0
+		case "<":
+			ok = result < 0
+		case "!=":
+			ok = result != 0
+		default:
+			ok = result == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
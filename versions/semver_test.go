@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package versions
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		value      string
+		ok         bool
+		parts      []int
+		prerelease string
+	}{
+		{value: "2.1.0", ok: true, parts: []int{2, 1, 0}},
+		{value: "v1.4.2-rc.1", ok: true, parts: []int{1, 4, 2}, prerelease: "rc.1"},
+		{value: " 3.0.5+build.17 ", ok: true, parts: []int{3, 0, 5}},
+		{value: "2", ok: true, parts: []int{2}},
+		{value: "latest"},
+		{value: "main-7f3c2a1"},
+		{value: "2.x"},
+		{value: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			v, ok := parseVersion(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if len(v.parts) != len(tt.parts) || v.prerelease != tt.prerelease {
+				t.Fatalf("got %v, want %v-%s", v, tt.parts, tt.prerelease)
+			}
+			for i := range tt.parts {
+				if v.parts[i] != tt.parts[i] {
+					t.Errorf("got %v, want %v", v.parts, tt.parts)
+				}
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "2.1.0", b: "2.1.0", want: 0},
+		{a: "2.1", b: "2.1.0", want: 0},
+		{a: "2.10.0", b: "2.9.0", want: 1},
+		{a: "1.9.9", b: "2.0.0", want: -1},
+		{a: "2.0.0-rc.1", b: "2.0.0", want: -1},
+		{a: "2.0.0-rc.2", b: "2.0.0-rc.10", want: -1},
+		{a: "2.0.0-rc.1", b: "2.0.0-rc.1.1", want: -1},
+		{a: "2.0.0-beta", b: "2.0.0-alpha.5", want: 1},
+		{a: "2.0.0-1", b: "2.0.0-alpha", want: -1},
+		{a: "2.0.0+build.1", b: "2.0.0+build.2", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, _ := parseVersion(tt.a)
+			b, _ := parseVersion(tt.b)
+			if got := a.compare(b); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+			if got := b.compare(a); got != -tt.want {
+				t.Errorf("reversed got %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{constraint: ">=2.1.0, <3", version: "2.1.0", want: true},
+		{constraint: ">=2.1.0, <3", version: "2.9.17", want: true},
+		{constraint: ">=2.1.0, <3", version: "3.0.0", want: false},
+		{constraint: ">=2.1.0, <3", version: "3.0.0-rc.1", want: true},
+		{constraint: ">=2.1.0, <3", version: "2.1.0-rc.1", want: false},
+		{constraint: "<2.5", version: "v2.4.99", want: true},
+		{constraint: ">1.0.0", version: "1.0.0", want: false},
+		{constraint: "<=1.0.0", version: "1.0", want: true},
+		{constraint: "!=2.3.1", version: "2.3.1", want: false},
+		{constraint: "!=2.3.1", version: "2.3.2", want: true},
+		{constraint: "2.3", version: "2.3.0", want: true},
+		{constraint: "=2.3.0", version: "2.3.1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			c, err := parseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, ok := parseVersion(tt.version)
+			if !ok {
+				t.Fatalf("invalid version %s", tt.version)
+			}
+			if got := c.matches(v); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, value := range []string{"", ">=", ">=2.x", "~2.1", ">=2.1,"} {
+		if _, err := parseConstraint(value); err == nil {
+			t.Errorf("'%s' accepted", value)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+// Package versions reports the versions of the platform, the agent and the extensions of a bundle and checks them
+// against compatibility rules
+package versions
+
+import (
+	"encoding/json"
+	"github.com/steadybit/steadybit-debug/bundle"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SourceFiles are the files of a bundle the report is created from
+var SourceFiles = []string{
+	"platform/pods/*/info.yml",
+	"platform/pods/*/description.txt",
+	"agent/pods/*/info.yml",
+	"agent/pods/*/description.txt",
+	"extensions/*/*/pods/*/description.txt",
+	"extensions/*/*/pods/*/http/GET__.yml",
+	"extensions/*/*/pods/*/https/GET__.yml",
+}
+
+var (
+	sectionPattern   = regexp.MustCompile(`^(Init Containers|Containers):\s*$`)
+	containerPattern = regexp.MustCompile(`^  ([\w.-]+):\s*$`)
+	imagePattern     = regexp.MustCompile(`^\s+Image:\s+(\S+)`)
+	imageIdPattern   = regexp.MustCompile(`^\s+Image ID:\s+(\S+)`)
+	kitKeyPattern    = regexp.MustCompile(`(?i)kit.*version`)
+)
+
+type Report struct {
+	Components []Component `json:"components"`
+	// Incompatibilities are only checked if compatibility rules are configured
+	Incompatibilities []Incompatibility `json:"incompatibilities,omitempty"`
+}
+
+type Component struct {
+	// Name is 'platform', 'agent' or 'extension <namespace>/<name>'
+	Name string `json:"name"`
+	// Version is the build version from info.yml or the tag of the first container's image
+	Version string  `json:"version,omitempty"`
+	Images  []Image `json:"images"`
+	// KitVersions are the kit API versions an extension advertises in its index, e.g. actionKitVersion
+	KitVersions map[string]string `json:"kitVersions,omitempty"`
+}
+
+type Image struct {
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	Tag       string `json:"tag,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+	// Pods running the image, a component lists an image once per distinct digest
+	Pods []string `json:"pods"`
+}
+
+// shortName returns the name of an extension without namespace, e.g. extension-http
+func (c Component) shortName() string {
+	if name, found := strings.CutPrefix(c.Name, "extension "); found {
+		return path.Base(name)
+	}
+	return c.Name
+}
+
+// NewReport lists the components of b and checks them against the configured compatibility rules
+func NewReport(b *bundle.Bundle) Report {
+	report := Report{Components: []Component{}}
+	for _, c := range b.Components() {
+		component := Component{Name: c.Name, Images: []Image{}}
+		infoVersions := map[string]bool{}
+		for _, pod := range c.Pods {
+			addImages(&component, b, pod)
+			if v := buildVersion(b, path.Join(pod, "info.yml")); v != "" {
+				infoVersions[v] = true
+			}
+			for _, scheme := range []string{"http", "https"} {
+				addKitVersions(&component, b, path.Join(pod, scheme, "GET__.yml"))
+			}
+		}
+		component.Version = joinSorted(infoVersions)
+		if component.Version == "" && len(component.Images) > 0 {
+			if _, ok := parseVersion(component.Images[0].Tag); ok {
+				component.Version = component.Images[0].Tag
+			}
+		}
+		report.Components = append(report.Components, component)
+	}
+	report.Incompatibilities = checkCompatibility(report.Components)
+	return report
+}
+
+// addImages adds the images of the containers of a described pod with the digest of the image ID
+func addImages(component *Component, b *bundle.Bundle, pod string) {
+	artifact, ok := b.Artifact(path.Join(pod, "description.txt"))
+	if !ok {
+		return
+	}
+	inContainers := false
+	var current *Image
+	add := func() {
+		if current == nil || current.Image == "" {
+			return
+		}
+		for i, image := range component.Images {
+			if image.Container == current.Container && image.Image == current.Image && image.Digest == current.Digest {
+				component.Images[i].Pods = append(component.Images[i].Pods, path.Base(pod))
+				return
+			}
+		}
+		component.Images = append(component.Images, *current)
+	}
+	for _, line := range artifact.Lines() {
+		switch {
+		case sectionPattern.MatchString(line):
+			inContainers = true
+		case line != "" && !strings.HasPrefix(line, " "):
+			inContainers = false
+		case !inContainers:
+		case containerPattern.MatchString(line):
+			add()
+			current = &Image{Container: containerPattern.FindStringSubmatch(line)[1], Pods: []string{path.Base(pod)}}
+		case current != nil && imagePattern.MatchString(line):
+			current.Image = imagePattern.FindStringSubmatch(line)[1]
+			current.Tag = imageTag(current.Image)
+			if _, digest, found := strings.Cut(current.Image, "@"); found {
+				current.Digest = digest
+			}
+		case current != nil && imageIdPattern.MatchString(line):
+			if _, digest, found := strings.Cut(imageIdPattern.FindStringSubmatch(line)[1], "@"); found {
+				current.Digest = digest
+			}
+		}
+	}
+	add()
+}
+
+// imageTag returns the tag of an image reference, e.g. ghcr.io/steadybit/agent:2.1.0@sha256:... -> 2.1.0
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	name := path.Base(image)
+	if _, tag, found := strings.Cut(name, ":"); found {
+		return tag
+	}
+	return ""
+}
+
+func buildVersion(b *bundle.Bundle, file string) string {
+	artifact, ok := b.Artifact(file)
+	if !ok || artifact.Error() != "" {
+		return ""
+	}
+	var info struct {
+		Build struct {
+			Version string `json:"version"`
+		} `json:"build"`
+	}
+	if json.Unmarshal([]byte(artifact.Body), &info) != nil {
+		return ""
+	}
+	return info.Build.Version
+}
+
+// addKitVersions adds the string values of top-level keys like actionKitVersion from an extension index
+func addKitVersions(component *Component, b *bundle.Bundle, file string) {
+	artifact, ok := b.Artifact(file)
+	if !ok || artifact.Error() != "" {
+		return
+	}
+	var index map[string]any
+	if json.Unmarshal([]byte(artifact.Body), &index) != nil {
+		return
+	}
+	for key, value := range index {
+		if v, ok := value.(string); ok && kitKeyPattern.MatchString(key) {
+			if component.KitVersions == nil {
+				component.KitVersions = map[string]string{}
+			}
+			component.KitVersions[key] = v
+		}
+	}
+}
+
+func joinSorted(values map[string]bool) string {
+	sorted := make([]string, 0, len(values))
+	for value := range values {
+		sorted = append(sorted, value)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
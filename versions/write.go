@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package versions
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// shortDigestLength keeps the algorithm and the first 12 hex characters, like container runtimes do
+const shortDigestLength = len("sha256:") + 12
+
+// Write prints the components as table, one row per image, followed by the incompatibilities if rules are configured
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tVERSION\tCONTAINER\tIMAGE TAG\tDIGEST\tKIT VERSIONS")
+	for _, component := range r.Components {
+		images := component.Images
+		if len(images) == 0 {
+			images = []Image{{}}
+		}
+		for i, image := range images {
+			name, version, kits := component.Name, orDash(component.Version), kitVersions(component.KitVersions)
+			if i > 0 {
+				name, version, kits = "", "", ""
+			}
+			digest := image.Digest
+			if len(digest) > shortDigestLength {
+				digest = digest[:shortDigestLength]
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", name, version, orDash(image.Container), orDash(image.Tag), orDash(digest), kits)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if !CompatibilityConfigured() {
+		return nil
+	}
+	if len(r.Incompatibilities) == 0 {
+		_, err := fmt.Fprintln(w, "\nNo incompatibilities found")
+		return err
+	}
+	fmt.Fprintln(w, "\nIncompatibilities:")
+	for _, incompatibility := range r.Incompatibilities {
+		fmt.Fprintf(w, "  %s: %s (rule %s)\n", strings.ToUpper(incompatibility.Severity), incompatibility.Message, incompatibility.Rule)
+	}
+	return nil
+}
+
+func kitVersions(versions map[string]string) string {
+	if len(versions) == 0 {
+		return "-"
+	}
+	entries := make([]string, 0, len(versions))
+	for key, value := range versions {
+		entries = append(entries, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ", ")
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}